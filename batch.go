@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/utagai/look/data"
+)
+
+// runBatch runs the query against the data, and writes the results to w as
+// JSON, one datum per line, or indented if pretty is set.
+func runBatch(ctx context.Context, d data.Data, q string, pretty bool, w io.Writer) error {
+	results, err := d.Find(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to run query %q: %w", q, err)
+	}
+
+	return writeResults(ctx, results, pretty, w)
+}
+
+// writeResults writes the results to w as JSON, one datum per line, or
+// indented if pretty is set.
+func writeResults(ctx context.Context, results data.Data, pretty bool, w io.Writer) error {
+	length, err := results.Length(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the number of results: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	for i := 0; i < length; i++ {
+		result, err := results.At(ctx, i)
+		if err != nil {
+			return fmt.Errorf("failed to get result #%d: %w", i, err)
+		}
+
+		out := []byte(result.String())
+		if pretty {
+			out, err = json.MarshalIndent(result, "", prettyIndent)
+			if err != nil {
+				return fmt.Errorf("failed to print result #%d: %w", i, err)
+			}
+		}
+		if _, err := fmt.Fprintf(bw, "%s\n", out); err != nil {
+			return fmt.Errorf("failed to print result #%d: %w", i, err)
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,137 @@
+// Package chart draws charts as lines of text, using block characters for the
+// fractions of a cell.
+package chart
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/utagai/look/internal/generics"
+)
+
+// horizontalEighths are the blocks that fill 1/8 to 7/8 of a cell from the
+// left, and verticalEighths those that fill it from the bottom.
+var (
+	horizontalEighths = []rune("▏▎▍▌▋▊▉")
+	verticalEighths   = []rune("▁▂▃▄▅▆▇")
+)
+
+const fullBlock = '█'
+
+// Bar is a bar of a bar chart.
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// Bars draws a horizontal bar chart of the given width, one line per bar. Each
+// line has the bar's label, the bar, and its value, aligned to the right. The
+// bars are scaled to the largest value, and negative values have no bar.
+func Bars(bars []Bar, width int) []string {
+	labelWidth := 0
+	valueWidth := 0
+	values := make([]string, len(bars))
+	max := 0.0
+	for i, bar := range bars {
+		labelWidth = generics.Max(labelWidth, utf8.RuneCountInString(bar.Label))
+		values[i] = FormatValue(bar.Value)
+		valueWidth = generics.Max(valueWidth, len(values[i]))
+		max = math.Max(max, bar.Value)
+	}
+	// Leave the bars at least half of the width.
+	labelWidth = generics.Min(labelWidth, width/3)
+	barWidth := generics.Max(width-labelWidth-valueWidth-2, 1)
+
+	lines := make([]string, len(bars))
+	for i, bar := range bars {
+		eighths := 0
+		if max > 0 && bar.Value > 0 {
+			eighths = int(math.Round(bar.Value / max * float64(barWidth*8)))
+		}
+
+		var sb strings.Builder
+		sb.WriteString(pad(bar.Label, labelWidth))
+		sb.WriteByte(' ')
+		drawn := eighths / 8
+		sb.WriteString(strings.Repeat(string(fullBlock), drawn))
+		if eighths%8 != 0 {
+			sb.WriteRune(horizontalEighths[eighths%8-1])
+			drawn++
+		}
+		sb.WriteString(strings.Repeat(" ", barWidth-drawn))
+		sb.WriteByte(' ')
+		sb.WriteString(strings.Repeat(" ", valueWidth-len(values[i])))
+		sb.WriteString(values[i])
+		lines[i] = sb.String()
+	}
+
+	return lines
+}
+
+// Columns draws a column chart of the values, height lines tall and at most
+// width wide, from the top line down. The values share the width, with any
+// left over on the right, and those that don't fit are left out. The columns
+// rise from the smallest value, or from 0 if none are negative.
+func Columns(values []float64, width, height int) []string {
+	if len(values) > width {
+		values = values[:width]
+	}
+	if len(values) == 0 || height <= 0 {
+		return nil
+	}
+	columnWidth := width / len(values)
+
+	min, max := 0.0, values[0]
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+
+	eighths := make([]int, len(values))
+	for i, v := range values {
+		if max > min {
+			eighths[i] = int(math.Round((v - min) / (max - min) * float64(height*8)))
+		}
+	}
+
+	lines := make([]string, height)
+	for row := range lines {
+		// The number of eighths below this row.
+		below := (height - 1 - row) * 8
+
+		var sb strings.Builder
+		for _, e := range eighths {
+			cell := ' '
+			switch filled := e - below; {
+			case filled >= 8:
+				cell = fullBlock
+			case filled > 0:
+				cell = verticalEighths[filled-1]
+			}
+			sb.WriteString(strings.Repeat(string(cell), columnWidth))
+		}
+		lines[row] = sb.String()
+	}
+
+	return lines
+}
+
+// FormatValue formats the value compactly, e.g. 120 or 0.333333.
+func FormatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// pad pads or truncates the string to the given width.
+func pad(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		if width == 0 {
+			return ""
+		}
+		return string(runes[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(runes))
+}
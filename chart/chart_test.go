@@ -0,0 +1,64 @@
+package chart_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utagai/look/chart"
+)
+
+func TestBars(t *testing.T) {
+	lines := chart.Bars([]chart.Bar{
+		{Label: "a", Value: 4},
+		{Label: "bb", Value: 1},
+		{Label: "c", Value: 0},
+		{Label: "d", Value: -2},
+	}, 12)
+
+	assert.Equal(t, []string{
+		"a  ██████  4",
+		"bb █▌      1",
+		"c          0",
+		"d         -2",
+	}, lines)
+}
+
+func TestBarsPartial(t *testing.T) {
+	lines := chart.Bars([]chart.Bar{
+		{Label: "a", Value: 8},
+		{Label: "b", Value: 3},
+	}, 10)
+
+	// The bars are 6 wide, so 3 is 2 and a quarter cells.
+	assert.Equal(t, []string{
+		"a ██████ 8",
+		"b ██▎    3",
+	}, lines)
+}
+
+func TestBarsLongLabels(t *testing.T) {
+	lines := chart.Bars([]chart.Bar{{Label: "abcdefgh", Value: 1}}, 12)
+
+	assert.Equal(t, []string{"abc… █████ 1"}, lines)
+}
+
+func TestColumns(t *testing.T) {
+	lines := chart.Columns([]float64{0, 1, 2, 4}, 8, 2)
+
+	assert.Equal(t, []string{
+		"      ██",
+		"  ▄▄████",
+	}, lines)
+}
+
+func TestColumnsTooMany(t *testing.T) {
+	lines := chart.Columns([]float64{1, 2, 3}, 2, 1)
+
+	assert.Equal(t, []string{"▄█"}, lines)
+}
+
+func TestColumnsNegative(t *testing.T) {
+	lines := chart.Columns([]float64{-1, 1}, 2, 1)
+
+	assert.Equal(t, []string{" █"}, lines)
+}
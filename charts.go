@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/utagai/look/chart"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+const (
+	// maxChartPoints is the number of results charted at most.
+	maxChartPoints = 500
+	// chartHeight is the height of the column charts of series.
+	chartHeight = 8
+	chartHint   = "Charts are drawn for queries that end in a group or a bucket stage."
+)
+
+// chartSpec is what to chart of the results of a query: the value of a field
+// against the value of another, the key.
+type chartSpec struct {
+	title      string
+	key, value string
+	// series is whether the keys are ordered, like numbers and times, rather
+	// than categories. It is decided by the keys themselves for groups.
+	series bool
+}
+
+// chartSpecFor returns what to chart of the results of the query, if anything.
+// Queries that end in a group are charted by their groups, and those that end
+// in a bucket by their buckets.
+func chartSpecFor(q string) (*chartSpec, bool) {
+	stages, err := breeze.NewParser(q).Parse()
+	if err != nil || len(stages) == 0 {
+		return nil, false
+	}
+
+	switch stage := stages[len(stages)-1].(type) {
+	case *breeze.Group:
+		spec := &chartSpec{
+			title: fmt.Sprintf("%s of .%s", stage.AggFunc, stage.AggregateField),
+			value: stage.AggregateAs(),
+		}
+		if stage.GroupByField != nil {
+			spec.title += fmt.Sprintf(" by .%s", *stage.GroupByField)
+			spec.key = *stage.GroupByField
+		}
+		return spec, true
+	case *breeze.Bucket:
+		return &chartSpec{
+			title:  fmt.Sprintf("count per bucket of .%s", stage.Field),
+			key:    "lower",
+			value:  "count",
+			series: true,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// chartPane charts the results of queries that end in a group or a bucket
+// stage, with a bar per category, or columns for a series of numbers or times.
+type chartPane struct {
+	*holder.Widget
+	// sample is the first of the results to chart.
+	sample []datum.Datum
+	query  string
+	// shown is whether the pane is showing, since the chart is only worth
+	// drawing if it is.
+	shown bool
+}
+
+func newChartPane() *chartPane {
+	return &chartPane{
+		Widget: holder.New(text.New("")),
+	}
+}
+
+// SetData sets the results to chart, by their first datums, which are those of
+// the given query.
+func (c *chartPane) SetData(app gowid.IApp, sample []datum.Datum, q string) {
+	c.sample = sample
+	c.query = q
+	if c.shown {
+		c.refresh(app)
+	}
+}
+
+// SetShown implements the sidebar interface.
+func (c *chartPane) SetShown(app gowid.IApp, shown bool) {
+	c.shown = shown
+	if shown {
+		c.refresh(app)
+	}
+}
+
+func (c *chartPane) refresh(app gowid.IApp) {
+	spec, ok := chartSpecFor(c.query)
+	if !ok {
+		c.show(app, "chart", chartHint)
+		return
+	}
+
+	labels, values, series := c.points(spec)
+	if len(values) == 0 {
+		c.show(app, spec.title, "There is nothing to chart.")
+		return
+	}
+
+	var lines []string
+	if series {
+		lines = chart.Columns(values, sidebarWidth, chartHeight)
+		// Label the top and bottom of the columns, which rise from 0 unless
+		// there are negative values.
+		low, high := 0.0, values[0]
+		for _, v := range values {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+		lines = append([]string{chart.FormatValue(high)}, lines...)
+		lines = append(lines, chart.FormatValue(low))
+		shown := len(values)
+		if shown > sidebarWidth {
+			shown = sidebarWidth
+		}
+		lines = append(lines, fmt.Sprintf("%s … %s", labels[0], labels[shown-1]))
+		if shown < len(values) {
+			lines = append(lines, fmt.Sprintf("(the first %d of %d)", shown, len(values)))
+		}
+	} else {
+		bars := make([]chart.Bar, len(values))
+		for i := range values {
+			bars[i] = chart.Bar{Label: labels[i], Value: values[i]}
+		}
+		lines = chart.Bars(bars, sidebarWidth)
+	}
+
+	c.show(app, spec.title, strings.Join(lines, "\n"))
+}
+
+// points returns the labels and values of the results to chart, and whether
+// they are a series. Results whose value isn't a number are left out.
+func (c *chartPane) points(spec *chartSpec) ([]string, []float64, bool) {
+	var labels []string
+	var values []float64
+	series := spec.series
+	numericKeys := true
+	for i, d := range c.sample {
+		if i == maxChartPoints {
+			break
+		}
+
+		value, ok := toFloat(d[spec.value])
+		if !ok {
+			continue
+		}
+
+		label := "all"
+		if spec.key != "" {
+			// Groups report nested keys as nested objects.
+			key, _ := d.Lookup(strings.Split(spec.key, "."))
+			if _, isNumber := toFloat(key); !isNumber {
+				numericKeys = false
+			}
+			label = fieldValue(key)
+		}
+		labels = append(labels, label)
+		values = append(values, value)
+	}
+
+	// Numeric groups are as good as buckets, and come in order.
+	if spec.key != "" && numericKeys && len(values) > 1 {
+		series = true
+	}
+
+	return labels, values, series
+}
+
+// toFloat returns the value as a float, if it is a number. Aggregates come in
+// all sorts of number types, e.g. counts are unsigned.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func (c *chartPane) show(app gowid.IApp, title, body string) {
+	c.SetSubWidget(pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: styled.New(text.New(title), gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+		&gowid.ContainerWidget{
+			IWidget: text.New(body),
+			D:       gowid.RenderFlow{},
+		},
+	}), app)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// copyToClipboard copies the text to the system clipboard with the OSC 52
+// escape sequence. This is supported by most terminal emulators, and unlike the
+// alternatives, it also works over SSH.
+func copyToClipboard(s string) error {
+	// The screen belongs to the UI, so write to the terminal directly, rather
+	// than through stdout.
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open the terminal: %w", err)
+	}
+	defer tty.Close()
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	if _, err := fmt.Fprintf(tty, "\x1b]52;c;%s\a", encoded); err != nil {
+		return fmt.Errorf("failed to write to the terminal: %w", err)
+	}
+
+	return nil
+}
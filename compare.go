@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/query"
+)
+
+// comparePane shows the results of a second query, B, beside those of the
+// main query, A, so that the two can be compared, e.g. the errors before and
+// after a deploy. Both queries run against the same data. Instead of the
+// results of B, it can show the results of A that aren't in B, going by the
+// value of a field.
+type comparePane struct {
+	gowid.IWidget
+	textbox *edit.Widget
+	// frame holds the frame of the textbox, which is red while B is invalid.
+	frame          *holder.Widget
+	valid, invalid gowid.IWidget
+	results        *resultsView
+	counts         *text.Widget
+	runner         *queryRunner
+
+	// a are the results of A, and b those of B.
+	a, b data.Data
+	// result is the result of B, once it has run.
+	result *queryResult
+	// key is the path of the field that the difference goes by, or nil if the
+	// results of B are showing instead.
+	key []string
+	// cancel cancels the difference being read, if any.
+	cancel context.CancelFunc
+}
+
+func newComparePane(d data.Data, newWalker func(data.Data) *data.DataWalker) *comparePane {
+	c := &comparePane{
+		textbox: edit.New(edit.Options{Caption: "Query B: "}),
+		results: newResultsView(d, newWalker),
+		counts:  text.New(""),
+		a:       d,
+		b:       d,
+	}
+	c.valid = framed.New(c.textbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorGreen),
+	})
+	c.invalid = framed.New(c.textbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorRed),
+	})
+	c.frame = holder.New(c.valid)
+	c.runner = newQueryRunner(d, 0, c.setRunning, c.setResult)
+	c.textbox.OnTextSet(gowid.WidgetCallback{
+		Name: "on query B text change",
+		WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+			c.runner.Submit(app, c.textbox.Text())
+		},
+	})
+
+	c.IWidget = pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: c.frame,
+			D:       gowid.RenderFlow{},
+		},
+		&gowid.ContainerWidget{
+			IWidget: styled.New(c.counts, gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+		&gowid.ContainerWidget{
+			IWidget: c.results,
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+	})
+	return c
+}
+
+// SetShown implements the sidebar interface. B keeps its results while it is
+// hidden, so there is nothing to do.
+func (c *comparePane) SetShown(gowid.IApp, bool) {}
+
+// Dimension implements the dimensioned interface. A and B share the width.
+func (c *comparePane) Dimension() gowid.IWidgetDimension {
+	return gowid.RenderWithWeight{W: 1}
+}
+
+// Walker returns the walker of the results showing.
+func (c *comparePane) Walker() *data.DataWalker {
+	return c.results.Walker()
+}
+
+// SetBaseline sets the results of A.
+func (c *comparePane) SetBaseline(app gowid.IApp, d data.Data) {
+	c.a = d
+	if c.key != nil {
+		c.refresh(app)
+	}
+}
+
+// Cancel cancels B and the difference, if they are running, e.g. when the tab
+// closes.
+func (c *comparePane) Cancel() {
+	c.runner.Cancel()
+	c.cancelDifference()
+}
+
+func (c *comparePane) cancelDifference() {
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// Differing returns whether the difference of A and B is showing.
+func (c *comparePane) Differing() bool {
+	return c.key != nil
+}
+
+// SetDifference shows the results of A that aren't in B, going by the value of
+// the field at the given path, or the results of B if the path is nil.
+func (c *comparePane) SetDifference(app gowid.IApp, path []string) {
+	c.key = path
+	c.refresh(app)
+}
+
+func (c *comparePane) setRunning(app gowid.IApp, elapsed time.Duration) {
+	c.counts.SetText(fmt.Sprintf("%c running… %.1fs", spinner(elapsed), elapsed.Seconds()), app)
+}
+
+func (c *comparePane) setResult(app gowid.IApp, result queryResult) {
+	if result.err != nil {
+		// Like A, B keeps its previous results on screen.
+		if errors.Is(result.err, query.ErrUnableToParseQuery) {
+			c.frame.SetSubWidget(c.invalid, app)
+		}
+		c.counts.SetText(result.err.Error(), app)
+		return
+	}
+
+	c.frame.SetSubWidget(c.valid, app)
+	c.b = result.data
+	c.result = &result
+	c.refresh(app)
+}
+
+// refresh shows the results of B, or the difference of A and B. The difference
+// is read in the background, since it reads all of A and B, and a refresh
+// cancels the one before it.
+func (c *comparePane) refresh(app gowid.IApp) {
+	c.cancelDifference()
+	if c.key == nil {
+		c.results.SetData(app, c.b, c.textbox.Text())
+		if c.result == nil {
+			c.counts.SetText("All of the data.", app)
+			return
+		}
+		c.counts.SetText(fmt.Sprintf("%d results in %v.", c.result.length, roundDuration(c.result.elapsed)), app)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	a, b, key := c.a, c.b, c.key
+	field := "." + strings.Join(key, ".")
+	c.counts.SetText(fmt.Sprintf("Comparing by %s…", field), app)
+
+	go func() {
+		difference, err := data.Difference(ctx, a, b, key)
+		// Reading the difference has read all of A, so its length is known.
+		var length, total int
+		if err == nil {
+			length, _ = difference.Length(ctx)
+			total, _ = a.Length(ctx)
+		}
+
+		_ = app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			// The difference may have been cancelled, or superseded by another.
+			if ctx.Err() != nil {
+				return
+			}
+			c.cancelDifference()
+
+			if err != nil {
+				c.counts.SetText(fmt.Sprintf("Failed to compare by %s: %v", field, err), app)
+				return
+			}
+			c.results.SetData(app, difference, "")
+			c.counts.SetText(fmt.Sprintf("%d of the %s of A aren't in B by %s.", length, plural(total, "result"), field), app)
+		}))
+	}()
+}
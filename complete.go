@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+// numFieldSampleDatums is the number of sampled datums whose fields are
+// completed.
+const numFieldSampleDatums = 100
+
+// completer completes the word before the cursor of the query textbox.
+type completer struct {
+	textbox *edit.Widget
+	// fields returns the fields that may be completed.
+	fields func() []string
+
+	// cycle is the candidates of the last completion if there were several, so
+	// that completing again cycles through them. It is only used while the text
+	// is still as the last completion left it.
+	cycle    []string
+	index    int
+	start    int
+	lastText string
+	// keys name the key that completes in the messages.
+	keys *keymap
+}
+
+func newCompleter(textbox *edit.Widget, fields func() []string, keys *keymap) *completer {
+	return &completer{
+		textbox: textbox,
+		fields:  fields,
+		keys:    keys,
+	}
+}
+
+// Complete completes the word before the cursor, and returns a message about
+// the completion for the status bar.
+func (c *completer) Complete(app gowid.IApp) string {
+	text := []rune(c.textbox.Text())
+	pos := c.textbox.CursorPos()
+
+	if c.cycle != nil && string(text) == c.lastText {
+		c.index = (c.index + 1) % len(c.cycle)
+		c.replace(app, text, c.start, pos, c.cycle[c.index])
+		return c.candidatesMessage(c.cycle, c.index)
+	}
+	c.cycle = nil
+
+	word, candidates := breeze.Complete(string(text[:pos]), c.fields())
+	start := pos - len([]rune(word))
+	switch len(candidates) {
+	case 0:
+		return fmt.Sprintf("Nothing to complete %q with.", word)
+	case 1:
+		completion := candidates[0]
+		// Functions continue with their arguments, everything else with a new
+		// word.
+		if !strings.HasSuffix(completion, "(") {
+			completion += " "
+		}
+		c.replace(app, text, start, pos, completion)
+		return ""
+	}
+
+	c.cycle = candidates
+	c.index = -1
+	c.start = start
+	if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+		c.replace(app, text, start, pos, prefix)
+	} else {
+		c.index = 0
+		c.replace(app, text, start, pos, candidates[0])
+	}
+
+	return c.candidatesMessage(candidates, c.index)
+}
+
+// replace replaces the text between start and end with the completion, and
+// moves the cursor to the end of it.
+func (c *completer) replace(app gowid.IApp, text []rune, start, end int, completion string) {
+	replaced := make([]rune, 0, len(text)+len(completion))
+	replaced = append(replaced, text[:start]...)
+	replaced = append(replaced, []rune(completion)...)
+	replaced = append(replaced, text[end:]...)
+
+	c.lastText = string(replaced)
+	c.textbox.SetText(c.lastText, app)
+	c.textbox.SetCursorPos(start+len([]rune(completion)), app)
+}
+
+// candidatesMessage lists the candidates, marking the one at the given index.
+func (c *completer) candidatesMessage(candidates []string, index int) string {
+	marked := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		if i == index {
+			candidate = "[" + candidate + "]"
+		}
+		marked[i] = candidate
+	}
+
+	return fmt.Sprintf("%s cycles through: %s", c.keys.Label(actionComplete), strings.Join(marked, " "))
+}
+
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
+
+// sampleFields returns the paths of the fields of the first of the sampled
+// datums, sorted. Fields that can't be written in a query, and those in arrays,
+// are left out.
+func sampleFields(sample []datum.Datum) []string {
+	if len(sample) > numFieldSampleDatums {
+		sample = sample[:numFieldSampleDatums]
+	}
+
+	seen := map[string]bool{}
+	for _, d := range sample {
+	fields:
+		for _, field := range d.FieldSpans() {
+			for _, key := range field.Path {
+				if !simpleKeyRegex.MatchString(key) {
+					continue fields
+				}
+			}
+			seen[strings.Join(field.Path, ".")] = true
+		}
+	}
+
+	fields := make([]string, 0, len(seen))
+	for field := range seen {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
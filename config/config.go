@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -29,16 +30,64 @@ type Config struct {
 		MongoDB string
 	}
 	CustomFields *custom.Fields
+	// Theme is the name of the color theme of the UI.
+	Theme string
+	// Pretty is whether to show each datum as indented, multi-line JSON.
+	Pretty bool
+	// HistoryPerSource is whether to only recall the queries that were run
+	// against the same source.
+	HistoryPerSource bool
+	// Palette overrides entries of the theme's palette, and Keys maps actions
+	// to the keys that trigger them. Both are only set in the config file.
+	Palette map[string]PaletteEntry
+	Keys    map[string]string
+	// SavedQueries are the queries saved in the config file, by name.
+	SavedQueries map[string]string
+	// Query is the query to start with, if any.
+	Query string
+	// Batch is whether to print the results of the query and exit, rather than
+	// start the UI.
+	Batch bool
 }
 
-// Get returns the config for the current look process.
+// Get returns the config for the current look process. Flags take precedence
+// over the config file.
 func Get() (*Config, error) {
+	configPtr := flag.String("config", "", "the config file (default is config.yaml in the look directory of the user config directory)")
 	sourcePtr := flag.String("source", "", "the source of data")
 	mongodbPtr := flag.String("mongodb", "", "specify the MongoDB connection string URI")
 	customParsePtr := flag.Bool("custom-parse", false, "enables custom parsing of the input into JSON")
+	themePtr := flag.String("theme", "dark", "the color theme: dark, light or mono")
+	prettyPtr := flag.Bool("pretty", false, "show each datum as indented, multi-line JSON")
+	historyPerSourcePtr := flag.Bool("history-per-source", false, "only recall the queries run against the same source")
+	queryPtr := flag.String("query", "", "the query to start with")
+	savedPtr := flag.String("saved", "", "the name of a query saved in the config file to start with")
+	batchPtr := flag.Bool("batch", false, "print the results of the query as JSON lines and exit")
 
 	flag.Parse()
 
+	// The flags that were given explicitly, as opposed to left to their
+	// defaults.
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	//// Load the config file.
+	configPath := *configPtr
+	if configPath == "" {
+		var err error
+		configPath, err = DefaultFilePath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Only insist on the config file existing if it was asked for.
+	file, err := LoadFile(configPath, set["config"])
+	if err != nil {
+		return nil, err
+	}
+
 	//// Validate.
 	if *sourcePtr == "" {
 		log.Fatalf("must specify a source of data")
@@ -50,7 +99,6 @@ func Get() (*Config, error) {
 	// Source
 	source := *sourcePtr
 	fi := os.Stdin
-	var err error
 	if source != "-" {
 		fi, err = os.Open(source)
 		if err != nil {
@@ -62,14 +110,27 @@ func Get() (*Config, error) {
 
 	// Backend type.
 	cfg.Backend.Type = BackendTypeMemory
-	if *mongodbPtr != "" {
+	switch {
+	case *mongodbPtr != "":
 		cfg.Backend.Type = BackendTypeMongoDB
 		cfg.Backend.MongoDB = *mongodbPtr
+	case file.Backend == BackendTypeMongoDB:
+		if file.MongoDB == "" {
+			return nil, errors.New("the config file's backend is mongodb, but it has no mongodb connection string URI")
+		}
+		cfg.Backend.Type = BackendTypeMongoDB
+		cfg.Backend.MongoDB = file.MongoDB
+	case file.Backend != "" && file.Backend != BackendTypeMemory:
+		return nil, fmt.Errorf("unexpected backend type %q in the config file", file.Backend)
 	}
 
 	// Custom fields.
 	if *customParsePtr {
-		parseFields, err := custom.ParseFields(flag.Args())
+		fieldSpecs := flag.Args()
+		if len(fieldSpecs) == 0 {
+			fieldSpecs = file.CustomFields
+		}
+		parseFields, err := custom.ParseFields(fieldSpecs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse the custom parser regex options: %w", err)
 		}
@@ -79,5 +140,39 @@ func Get() (*Config, error) {
 		cfg.CustomFields = nil
 	}
 
+	// Display.
+	cfg.Theme = *themePtr
+	if !set["theme"] && file.Theme != "" {
+		cfg.Theme = file.Theme
+	}
+	cfg.Pretty = *prettyPtr
+	if !set["pretty"] {
+		cfg.Pretty = file.Pretty
+	}
+	cfg.Palette = file.Palette
+	cfg.Keys = file.Keys
+
+	// History.
+	cfg.HistoryPerSource = *historyPerSourcePtr
+	if !set["history-per-source"] {
+		cfg.HistoryPerSource = file.HistoryPerSource
+	}
+
+	// Queries.
+	cfg.SavedQueries = file.Queries
+	cfg.Query = *queryPtr
+	if *savedPtr != "" {
+		if *queryPtr != "" {
+			return nil, errors.New("only one of -query and -saved may be given")
+		}
+
+		query, ok := file.Queries[*savedPtr]
+		if !ok {
+			return nil, fmt.Errorf("there is no saved query named %q in the config file (%q)", *savedPtr, configPath)
+		}
+		cfg.Query = query
+	}
+	cfg.Batch = *batchPtr
+
 	return &cfg, nil
 }
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the config file. Its settings are the defaults of the flags of the
+// same names, and the settings that have no flags.
+//
+// An example:
+//
+//	backend: mongodb
+//	mongodb: mongodb://localhost:27017
+//	theme: light
+//	pretty: true
+//	history_per_source: true
+//	palette:
+//	  jkey: {fg: "#5f87af", bg: default}
+//	keys:
+//	  table: F6
+//	custom_fields:
+//	  - level=\[(\w+)\]
+//	queries:
+//	  errors: filter .level = "error" | sort .time desc
+type File struct {
+	Backend          BackendType `yaml:"backend"`
+	MongoDB          string      `yaml:"mongodb"`
+	Theme            string      `yaml:"theme"`
+	Pretty           bool        `yaml:"pretty"`
+	HistoryPerSource bool        `yaml:"history_per_source"`
+	// Palette overrides entries of the theme's palette.
+	Palette map[string]PaletteEntry `yaml:"palette"`
+	// Keys maps actions to the keys that trigger them, e.g. table: F6.
+	Keys map[string]string `yaml:"keys"`
+	// CustomFields are the field specs used with -custom-parse if none are
+	// given as arguments.
+	CustomFields []string `yaml:"custom_fields"`
+	// Queries are saved queries, by name.
+	Queries map[string]string `yaml:"queries"`
+}
+
+// PaletteEntry is the colors of an entry of the palette. Colors are either
+// names, like cyan, or hex codes, like #5f87af.
+type PaletteEntry struct {
+	FG string `yaml:"fg"`
+	BG string `yaml:"bg"`
+}
+
+// DefaultFilePath returns the path of the config file in the user's config
+// directory.
+func DefaultFilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "look", "config.yaml"), nil
+}
+
+// LoadFile loads the config file at the given path. A missing file is an empty
+// config, unless it is required.
+func LoadFile(path string, required bool) (*File, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return &File{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open the config file (%q): %w", path, err)
+	}
+	defer f.Close()
+
+	var file File
+	decoder := yaml.NewDecoder(f)
+	// Catch typos, rather than silently ignoring them.
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse the config file (%q): %w", path, err)
+	}
+
+	return &file, nil
+}
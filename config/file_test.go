@@ -0,0 +1,73 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utagai/look/config"
+)
+
+func writeFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeFile(t, `
+backend: mongodb
+mongodb: mongodb://localhost:27017
+theme: light
+pretty: true
+palette:
+  jkey: {fg: cyan, bg: default}
+keys:
+  table: F6
+custom_fields:
+  - level=\[(\w+)\]
+queries:
+  errors: filter .level = "error"
+`)
+
+	file, err := config.LoadFile(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, &config.File{
+		Backend: config.BackendTypeMongoDB,
+		MongoDB: "mongodb://localhost:27017",
+		Theme:   "light",
+		Pretty:  true,
+		Palette: map[string]config.PaletteEntry{
+			"jkey": {FG: "cyan", BG: "default"},
+		},
+		Keys:         map[string]string{"table": "F6"},
+		CustomFields: []string{`level=\[(\w+)\]`},
+		Queries:      map[string]string{"errors": `filter .level = "error"`},
+	}, file)
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	file, err := config.LoadFile(path, false)
+	require.NoError(t, err)
+	assert.Equal(t, &config.File{}, file)
+
+	_, err = config.LoadFile(path, true)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadFileEmpty(t *testing.T) {
+	file, err := config.LoadFile(writeFile(t, ""), true)
+	require.NoError(t, err)
+	assert.Equal(t, &config.File{}, file)
+}
+
+func TestLoadFileUnknownSetting(t *testing.T) {
+	_, err := config.LoadFile(writeFile(t, "colour: red\n"), true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "colour")
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query"
 )
 
 var (
@@ -23,3 +24,26 @@ type Data interface {
 	// Length returns the number of datums in this Data.
 	Length(context.Context) (int, error)
 }
+
+// Profiled is implemented by Data that know how the query that produced them
+// was executed.
+type Profiled interface {
+	// Profile returns the stats of each stage of the query that produced the
+	// data, or nil if there is no such query.
+	Profile() []query.StageStats
+}
+
+// Closer is implemented by Data that hold on to resources, e.g. a connection,
+// until they are closed. The results found in the data share its resources, so
+// closing any of them closes them all.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Matched is implemented by Data that know which parts of their datums matched
+// the query that produced them.
+type Matched interface {
+	// Matches returns the spans of the JSON of the datum, as given by
+	// datum.Datum.String(), that matched the query that produced the data.
+	Matches(d datum.Datum) []datum.Span
+}
@@ -3,6 +3,8 @@ package data_test
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -137,3 +139,230 @@ func testDataAt(t *testing.T, data data.Data, _ dataTestCase) {
 		assert.Equal(t, getTestDatum(i), datum)
 	}
 }
+
+// Queries are found on background goroutines, and a cancelled query may still
+// be running when the next one starts, so finds must be safe to run at once.
+// This is best run with -race.
+func TestMongoDBDataConcurrentFinds(t *testing.T) {
+	d := newMongoDBData(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				q := fmt.Sprintf(`[{"$match": {"foo.a": {"$gte": %d}}}]`, i*10+j)
+				_, err := d.Find(ctx, q)
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Every query should run against the base data as it was originally given,
+// regardless of what the queries before it did.
+func TestMemoryDataFindDoesNotMutateBaseData(t *testing.T) {
+	ctx := context.Background()
+	data := data.NewMemoryData(testDatums, query.NewLiquidQueryExecutor())
+
+	queries := []string{
+		"map .foo.a = 0",
+		"map .foo.c.d = 0",
+		"rename .bar as .qux",
+		"unset .baz, .foo.b",
+	}
+	for _, q := range queries {
+		_, err := data.Find(ctx, q)
+		assert.NoError(t, err)
+
+		for i := 0; i < numTestDatums; i++ {
+			datum, err := data.At(ctx, i)
+			assert.NoError(t, err)
+			assert.Equal(t, getTestDatum(i), datum)
+		}
+	}
+}
+
+func TestMemoryDataFindIsCancellable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, executor := range []query.Executor{
+		query.NewSubstringQueryExecutor(),
+		query.NewLiquidQueryExecutor(),
+	} {
+		data := data.NewMemoryData(testDatums, executor)
+		_, err := data.Find(ctx, "sort .foo.a")
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+func TestMemoryDataProfile(t *testing.T) {
+	ctx := context.Background()
+	base := data.NewMemoryData(testDatums, query.NewLiquidQueryExecutor())
+	assert.Nil(t, base.Profile())
+
+	found, err := base.Find(ctx, "filter .bar = true | sort .foo.a")
+	assert.NoError(t, err)
+	profile := found.(data.Profiled).Profile()
+	assert.Len(t, profile, 2)
+	assert.Equal(t, "filter", profile[0].Stage)
+	assert.Equal(t, numTestDatums, profile[0].RowsIn)
+	assert.Equal(t, "sort", profile[1].Stage)
+	assert.Equal(t, numTestDatums, profile[1].RowsOut)
+}
+
+func TestMemoryDataMatches(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		executor query.Executor
+		query    string
+		expected []string
+	}{
+		{
+			executor: query.NewSubstringQueryExecutor(),
+			query:    "world: 42",
+			expected: []string{"world: 42"},
+		},
+		{
+			executor: query.NewLiquidQueryExecutor(),
+			query:    `filter .baz contains "42"`,
+			expected: []string{"42"},
+		},
+	} {
+		base := data.NewMemoryData(testDatums, tc.executor)
+		assert.Empty(t, base.Matches(testDatums[0]))
+
+		found, err := base.Find(ctx, tc.query)
+		assert.NoError(t, err)
+		d, err := found.At(ctx, 0)
+		assert.NoError(t, err)
+
+		s := d.String()
+		matched := []string{}
+		for _, span := range found.(data.Matched).Matches(d) {
+			matched = append(matched, s[span.Start:span.End])
+		}
+		assert.Equal(t, tc.expected, matched)
+	}
+}
+
+func TestMemoryDataSearch(t *testing.T) {
+	d := newMemoryData(t)
+	matchesBaz := func(suffix string) func(datum.Datum) bool {
+		return func(d datum.Datum) bool {
+			return strings.HasSuffix(d["baz"].(string), suffix)
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from     int
+		backward bool
+		suffix   string
+		expected int
+	}{
+		{name: "forward", from: 10, suffix: "5!", expected: 15},
+		{name: "backward", from: 10, backward: true, suffix: "5!", expected: 5},
+		{name: "forward wraps around", from: 96, suffix: ": 3!", expected: 3},
+		{name: "backward wraps around", from: 2, backward: true, suffix: ": 97!", expected: 97},
+		{name: "lone match at the start is found again", from: 42, suffix: ": 42!", expected: 42},
+		{name: "no match", from: 0, suffix: "?", expected: -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := data.Search(context.Background(), d, tc.from, tc.backward, matchesBaz(tc.suffix))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, index)
+		})
+	}
+}
+
+func TestMemoryDataSearchIsCancellable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := data.Search(ctx, newMemoryData(t), 0, false, func(datum.Datum) bool { return false })
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDifference(t *testing.T) {
+	ctx := context.Background()
+	a := newMemoryData(t)
+	for _, tc := range []struct {
+		name     string
+		b        []datum.Datum
+		path     []string
+		expected []datum.Datum
+	}{
+		{
+			name:     "nothing in common",
+			b:        []datum.Datum{},
+			path:     []string{"baz"},
+			expected: testDatums,
+		},
+		{
+			name:     "everything in common",
+			b:        testDatums,
+			path:     []string{"baz"},
+			expected: []datum.Datum{},
+		},
+		{
+			name:     "nested field",
+			b:        testDatums[1:],
+			path:     []string{"foo", "a"},
+			expected: testDatums[:1],
+		},
+		{
+			name:     "same value in other datums",
+			b:        []datum.Datum{{"baz": "x", "foo": datum.Datum{"b": int64(5)}}},
+			path:     []string{"foo", "b"},
+			expected: append(append([]datum.Datum{}, testDatums[:4]...), testDatums[5:]...),
+		},
+		{
+			name:     "missing fields are equal",
+			b:        []datum.Datum{{"baz": "x"}},
+			path:     []string{"qux"},
+			expected: []datum.Datum{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := data.NewMemoryData(tc.b, query.NewSubstringQueryExecutor())
+			difference, err := data.Difference(ctx, a, b, tc.path)
+			assert.NoError(t, err)
+
+			length, err := difference.Length(ctx)
+			assert.NoError(t, err)
+			actual := make([]datum.Datum, length)
+			for i := range actual {
+				actual[i], err = difference.At(ctx, i)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDifferenceKeepsExecutor(t *testing.T) {
+	ctx := context.Background()
+	a := newMemoryData(t)
+	b := data.NewMemoryData([]datum.Datum{}, query.NewSubstringQueryExecutor())
+	difference, err := data.Difference(ctx, a, b, []string{"baz"})
+	assert.NoError(t, err)
+
+	// A substring isn't a valid breeze query, so this only succeeds with the
+	// substring executor of a.
+	_, err = difference.Find(ctx, "baz")
+	assert.NoError(t, err)
+}
+
+func TestDifferenceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := newMemoryData(t)
+	b := data.NewMemoryData([]datum.Datum{}, query.NewSubstringQueryExecutor())
+	_, err := data.Difference(ctx, a, b, []string{"baz"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
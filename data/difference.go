@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query"
+)
+
+// Difference returns the datums of a whose value at the given path is not the
+// value at that path of any datum of b, in the order they are in a. Datums that
+// lack the field count as having the same value as one another.
+//
+// The difference is read into memory, and can be queried like any other
+// in-memory data, with the executor of a if it is in memory too.
+func Difference(ctx context.Context, a, b Data, path []string) (*MemoryData, error) {
+	keys, err := keysOf(ctx, b, path)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := a.Length(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the length of the data: %w", err)
+	}
+	difference := []datum.Datum{}
+	for i := 0; i < length; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		d, err := a.At(ctx, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get datum #%d: %w", i, err)
+		}
+		key, err := keyOf(d, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the key of datum #%d: %w", i, err)
+		}
+		if _, ok := keys[key]; !ok {
+			difference = append(difference, d)
+		}
+	}
+
+	var executor query.Executor = query.NewLiquidQueryExecutor()
+	if md, ok := a.(*MemoryData); ok {
+		executor = md.executor
+	}
+
+	return NewMemoryData(difference, executor), nil
+}
+
+// keysOf returns the set of the keys of the datums of the data.
+func keysOf(ctx context.Context, d Data, path []string) (map[string]struct{}, error) {
+	length, err := d.Length(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the length of the data: %w", err)
+	}
+
+	keys := make(map[string]struct{}, length)
+	for i := 0; i < length; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		other, err := d.At(ctx, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get datum #%d: %w", i, err)
+		}
+		key, err := keyOf(other, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the key of datum #%d: %w", i, err)
+		}
+		keys[key] = struct{}{}
+	}
+
+	return keys, nil
+}
+
+// missingKey is the key of the datums that lack the field. It can't be
+// mistaken for the JSON of a value.
+const missingKey = ""
+
+// keyOf returns the key of the datum, which is the JSON of its value at the
+// path. Objects have their fields sorted in JSON, so equal values have equal
+// keys.
+func keyOf(d datum.Datum, path []string) (string, error) {
+	value, ok := d.Lookup(path)
+	if !ok {
+		return missingKey, nil
+	}
+
+	key, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(key), nil
+}
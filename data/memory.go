@@ -12,9 +12,13 @@ import (
 type MemoryData struct {
 	data     []datum.Datum
 	executor query.Executor
+	profile  []query.StageStats
+	matcher  func(datum.Datum) []datum.Span
 }
 
 var _ Data = (*MemoryData)(nil)
+var _ Profiled = (*MemoryData)(nil)
+var _ Matched = (*MemoryData)(nil)
 
 func NewMemoryData(data []datum.Datum, executor query.Executor) *MemoryData {
 	return &MemoryData{
@@ -23,12 +27,46 @@ func NewMemoryData(data []datum.Datum, executor query.Executor) *MemoryData {
 	}
 }
 
-func (md *MemoryData) Find(_ context.Context, q string) (Data, error) {
-	datums, err := md.executor.Find(q, md.data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute %q: %w", q, err)
+func (md *MemoryData) Find(ctx context.Context, q string) (Data, error) {
+	var found *MemoryData
+	if executor, ok := md.executor.(query.ProfilingExecutor); ok {
+		datums, profile, err := executor.FindProfiled(ctx, q, md.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute %q: %w", q, err)
+		}
+		found = NewMemoryData(datums, md.executor)
+		found.profile = profile
+	} else {
+		datums, err := md.executor.Find(ctx, q, md.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute %q: %w", q, err)
+		}
+		found = NewMemoryData(datums, md.executor)
 	}
-	return NewMemoryData(datums, md.executor), nil
+
+	if executor, ok := md.executor.(query.MatchingExecutor); ok {
+		matcher, err := executor.Matcher(q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match %q: %w", q, err)
+		}
+		found.matcher = matcher
+	}
+
+	return found, nil
+}
+
+// Matches implements the Matched interface.
+func (md *MemoryData) Matches(d datum.Datum) []datum.Span {
+	if md.matcher == nil {
+		return nil
+	}
+
+	return md.matcher(d)
+}
+
+// Profile implements the Profiled interface.
+func (md *MemoryData) Profile() []query.StageStats {
+	return md.profile
 }
 
 func (md *MemoryData) At(_ context.Context, index int) (datum.Datum, error) {
@@ -24,7 +24,10 @@ type MongoDBData struct {
 	cache     *mongoDBDataCache
 }
 
-var _ Data = (*MongoDBData)(nil)
+var (
+	_ Data   = (*MongoDBData)(nil)
+	_ Closer = (*MongoDBData)(nil)
+)
 
 // NewMongoDBData takes a URI and datums and provides a MongoDB-backed data.
 // Note that as per the official MongoDB driver behavior, the database &
@@ -106,3 +109,8 @@ func (md *MongoDBData) Find(ctx context.Context, q string) (Data, error) {
 func (md *MongoDBData) Length(ctx context.Context) (int, error) {
 	return md.resultSet.Length(ctx)
 }
+
+// Close implements the Closer interface. It disconnects from MongoDB.
+func (md *MongoDBData) Close(ctx context.Context) error {
+	return md.client.Disconnect(ctx)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 
 	"github.com/utagai/look/datum"
 	"github.com/utagai/look/query"
@@ -12,62 +15,124 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// lookupFilePrefix marks the 'from' of a $lookup stage as the path of a file,
+// rather than the name of a collection, e.g. "from": "file:users.json".
+const lookupFilePrefix = "file:"
+
 // mongoDBDataCache is a MongoDB-backed cache for query result set data.
 // This is unfortunately necessary because certain kinds of MongoDB queries do
 // not guarantee a deterministic ordering of their result documents. An example
 // of this is $group.
 // This cache works by taking the hex-encoded string of a pipeline, and
 // creating a new collection via $out of the result of running it against the
-// source collection. These resulting collections form the 'cache'.
+// source collection. These resulting collections form the 'cache'. Their names
+// are prefixed by the name of the source collection, since the collections of
+// several sources may share a database.
 type mongoDBDataCache struct {
-	sourceDB        *mongo.Database
-	sourceColl      *mongo.Collection
-	prefilter       []bson.M
+	sourceDB   *mongo.Database
+	sourceColl *mongo.Collection
+	prefilter  []bson.M
+
+	// mu guards the maps below. Queries are found on background goroutines, so
+	// a cancelled query may still be running when the next one starts, and
+	// several views may query the same data at once.
+	mu              sync.Mutex
 	queryToCollName map[string]string
+	// queryLookupFiles maps a query to the paths of the files its $lookup
+	// stages join against. Its cached results are stale once any of them
+	// change.
+	queryLookupFiles map[string][]string
+	// lookupSources maps the path of a secondary source referenced by a $lookup
+	// to the collection it was loaded into.
+	lookupSources map[string]lookupSource
+}
+
+// lookupSource is a file loaded into a collection for $lookup stages to join
+// against.
+type lookupSource struct {
+	collName string
+	// info is the info of the file when it was loaded.
+	info os.FileInfo
 }
 
 func newMongoDBDataCache(sourceDB *mongo.Database, sourceColl *mongo.Collection, prefilter []bson.M) *mongoDBDataCache {
 	return &mongoDBDataCache{
-		sourceDB:        sourceDB,
-		sourceColl:      sourceColl,
-		prefilter:       prefilter,
-		queryToCollName: make(map[string]string),
+		sourceDB:         sourceDB,
+		sourceColl:       sourceColl,
+		prefilter:        prefilter,
+		queryToCollName:  make(map[string]string),
+		queryLookupFiles: make(map[string][]string),
+		lookupSources:    make(map[string]lookupSource),
 	}
 }
 
-// newQuery creates a new query to be cached.
-func (m *mongoDBDataCache) newQuery(ctx context.Context, q string) error {
+// newQuery creates a new query to be cached, and returns the name of the
+// collection its results are cached in.
+func (m *mongoDBDataCache) newQuery(ctx context.Context, q string) (string, error) {
 	pipeline := []bson.M{}
 	if err := bson.UnmarshalExtJSON([]byte(q), true, &pipeline); err != nil {
-		return fmt.Errorf("%q: %w: %v", q, query.ErrUnableToParseQuery, err)
+		return "", fmt.Errorf("%q: %w: %v", q, query.ErrUnableToParseQuery, err)
+	}
+	lookupFiles, err := m.resolveLookupSources(ctx, pipeline)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve $lookup sources: %w", err)
 	}
-	pipelineHex := hex.EncodeToString([]byte(q))
-	outPipeline := append(pipeline, bson.M{"$out": pipelineHex})
+	collName := m.sourceColl.Name() + "." + hex.EncodeToString([]byte(q))
+	outPipeline := append(pipeline, bson.M{"$out": collName})
 	// $out produces an empty cursor, just make sure this did not error:
 	if _, err := m.sourceColl.Aggregate(ctx, outPipeline); err != nil {
-		return fmt.Errorf("failed to cache the results of the pipeline (%q): %w", q, err)
+		return "", fmt.Errorf("failed to cache the results of the pipeline (%q): %w", q, err)
 	}
 
-	m.queryToCollName[q] = pipelineHex
+	m.mu.Lock()
+	m.queryToCollName[q] = collName
+	m.queryLookupFiles[q] = lookupFiles
+	m.mu.Unlock()
 
-	return nil
+	return collName, nil
 }
 
 // runQuery runs the given query and/or returns an indexable result set with
 // deterministic ordering.
 func (m *mongoDBDataCache) runQuery(ctx context.Context, q string) (*indexableResult, error) {
-	if collNameForQuery, ok := m.queryToCollName[q]; ok {
-		return &indexableResult{
-			prefilter: m.prefilter,
-			coll:      m.sourceDB.Collection(collNameForQuery),
-		}, nil
-	} else {
-		if err := m.newQuery(ctx, q); err != nil {
+	m.mu.Lock()
+	collNameForQuery, ok := m.queryToCollName[q]
+	lookupFiles := m.queryLookupFiles[q]
+	m.mu.Unlock()
+	if !ok || m.lookupFilesChanged(lookupFiles) {
+		var err error
+		collNameForQuery, err = m.newQuery(ctx, q)
+		if err != nil {
 			return nil, fmt.Errorf("tried caching the results on fetch, but failed: %w", err)
 		}
+	}
+
+	return &indexableResult{
+		prefilter: m.prefilter,
+		coll:      m.sourceDB.Collection(collNameForQuery),
+	}, nil
+}
 
-		return m.runQuery(ctx, q)
+// lookupFilesChanged returns whether any of the files at the paths changed
+// since they were loaded.
+func (m *mongoDBDataCache) lookupFilesChanged(paths []string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, path := range paths {
+		source, ok := m.lookupSources[path]
+		if !ok || !unchanged(source.info, path) {
+			return true
+		}
 	}
+
+	return false
+}
+
+// unchanged returns whether the file at the path is as described by the info.
+func unchanged(info os.FileInfo, path string) bool {
+	current, err := os.Stat(path)
+	return err == nil && current.ModTime().Equal(info.ModTime()) && current.Size() == info.Size()
 }
 
 type indexableResult struct {
@@ -124,3 +189,80 @@ func (ir *indexableResult) Length(ctx context.Context) (int, error) {
 
 	return int(count64), nil
 }
+
+// resolveLookupSources rewrites the $lookup stages of the given pipeline whose
+// 'from' names a file, with lookupFilePrefix, rather than a collection. Such
+// files are loaded into their own collection (again whenever they change), and
+// the stage is pointed at it. This is the MongoDB equivalent of breeze's
+// lookup stage, which joins against a secondary source. It returns the paths
+// of the files.
+func (m *mongoDBDataCache) resolveLookupSources(ctx context.Context, pipeline []bson.M) ([]string, error) {
+	var paths []string
+	for _, stage := range pipeline {
+		lookup, ok := stage["$lookup"].(bson.M)
+		if !ok {
+			continue
+		}
+
+		from, ok := lookup["from"].(string)
+		if !ok || !strings.HasPrefix(from, lookupFilePrefix) {
+			// A collection, which is used as it is.
+			continue
+		}
+
+		path := strings.TrimPrefix(from, lookupFilePrefix)
+		collName, err := m.lookupSource(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %q: %w", path, err)
+		}
+
+		lookup["from"] = collName
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// lookupSource returns the name of the collection the file at the path is
+// loaded into, loading it if it isn't already, or if it changed since. The lock
+// is held while loading, since two queries loading the same file at once would
+// otherwise interleave their writes to its collection.
+func (m *mongoDBDataCache) lookupSource(ctx context.Context, path string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if source, ok := m.lookupSources[path]; ok && unchanged(source.info, path) {
+		return source.collName, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	collName, err := m.loadLookupSource(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	m.lookupSources[path] = lookupSource{collName: collName, info: info}
+
+	return collName, nil
+}
+
+func (m *mongoDBDataCache) loadLookupSource(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	datums, err := datum.ReadJSON(f)
+	if err != nil {
+		return "", err
+	}
+
+	collName := m.sourceColl.Name() + ".lookup_" + hex.EncodeToString([]byte(path))
+	if err := loadDataIntoMongoDB(ctx, m.sourceDB.Collection(collName), datums); err != nil {
+		return "", err
+	}
+
+	return collName, nil
+}
@@ -0,0 +1,47 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/utagai/look/datum"
+)
+
+// Search returns the index of the first datum after the given index that
+// matches, or before it if backward is set. The search wraps around the ends of
+// the data, and ends at the given index itself, so a lone match is found again.
+// It returns -1 if nothing matches.
+//
+// The search reads the datums one at a time, so it can take a while on large
+// data, and stops as soon as the context is cancelled.
+func Search(ctx context.Context, d Data, from int, backward bool, matches func(datum.Datum) bool) (int, error) {
+	length, err := d.Length(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("failed to get the length of the data: %w", err)
+	}
+	if length == 0 {
+		return -1, nil
+	}
+
+	step := 1
+	if backward {
+		step = -1
+	}
+	for i := 1; i <= length; i++ {
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+
+		// Go's % keeps the sign of the dividend, hence adding length.
+		index := ((from+i*step)%length + length) % length
+		candidate, err := d.At(ctx, index)
+		if err != nil {
+			return -1, fmt.Errorf("failed to get datum #%d: %w", index, err)
+		}
+		if matches(candidate) {
+			return index, nil
+		}
+	}
+
+	return -1, nil
+}
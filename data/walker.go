@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 
 	"github.com/gcla/gowid"
@@ -25,6 +26,13 @@ type DataWalker struct {
 	data  Data
 	focus list.IWalkerPosition
 	ctx   context.Context
+	// length is the last length successfully fetched from the data. We fall
+	// back to it if fetching the length fails.
+	length  int
+	onError func(error)
+	// lastErr is the last error we reported, if any.
+	lastErr string
+	render  func(datum.Datum) gowid.IWidget
 }
 
 var _ list.IBoundedWalker = (*DataWalker)(nil)
@@ -33,12 +41,59 @@ var _ list.IWalkerEnd = &DataWalker{}
 
 func NewDataWalker(data Data) *DataWalker {
 	return &DataWalker{
-		data:  data,
-		ctx:   context.Background(),
-		focus: list.ListPos(0),
+		data:   data,
+		ctx:    context.Background(),
+		focus:  list.ListPos(0),
+		render: createWidgetFor,
 	}
 }
 
+// SetRenderer sets the function that creates the widget for each datum. By
+// default, each datum is shown as a single line of JSON.
+func (dw *DataWalker) SetRenderer(render func(datum.Datum) gowid.IWidget) {
+	dw.render = render
+}
+
+// Data returns the data the walker walks over.
+func (dw *DataWalker) Data() Data {
+	return dw.data
+}
+
+// OnError registers a function to call when the walker fails to read from the
+// data. The walker can't return these errors itself, since it is driven by the
+// list widget. Note that the function is called from the rendering goroutine,
+// and that an error is not reported again if it repeats.
+func (dw *DataWalker) OnError(f func(error)) {
+	dw.onError = f
+}
+
+func (dw *DataWalker) reportError(err error) {
+	// The list widget will keep asking for whatever failed each time it is
+	// rendered, so avoid reporting the same error over and over.
+	if err.Error() == dw.lastErr {
+		return
+	}
+	dw.lastErr = err.Error()
+
+	log.Print(err)
+	if dw.onError != nil {
+		dw.onError(err)
+	}
+}
+
+// getLength returns the length of the data, falling back to the last known
+// length if it can't be fetched.
+func (dw *DataWalker) getLength() int {
+	length, err := dw.data.Length(dw.ctx)
+	if err != nil {
+		dw.reportError(fmt.Errorf("failed to get the length for data: %w", err))
+		return dw.length
+	}
+
+	dw.length = length
+	return length
+}
+
 // First implements the list.IWalkerHome interface.
 func (dw *DataWalker) First() list.IWalkerPosition {
 	return list.ListPos(0)
@@ -46,11 +101,7 @@ func (dw *DataWalker) First() list.IWalkerPosition {
 
 // Last implements the list.IWalkerEnd interface.
 func (dw *DataWalker) Last() list.IWalkerPosition {
-	length, err := dw.data.Length(dw.ctx)
-	if err != nil {
-		log.Fatalf("failed to fetch the length for data: %v", err)
-	}
-	return list.ListPos(length - 1)
+	return list.ListPos(dw.getLength() - 1)
 }
 
 func createWidgetFor(datum datum.Datum) gowid.IWidget {
@@ -77,11 +128,11 @@ func (dw *DataWalker) At(pos list.IWalkerPosition) gowid.IWidget {
 	if errors.Is(err, ErrOutOfBounds) {
 		return nil
 	} else if err != nil {
-		log.Printf("Failed to get datum at index %d: %v", index, err)
+		dw.reportError(fmt.Errorf("failed to get datum at index %d: %w", index, err))
 		return nil
 	}
 
-	return createWidgetFor(datum)
+	return dw.render(datum)
 }
 
 // Focus implements the list.IBoundedWalker interface.
@@ -97,11 +148,7 @@ func (dw *DataWalker) SetFocus(pos list.IWalkerPosition, app gowid.IApp) {
 // Next implements the list.IBoundedWalker interface.
 func (dw *DataWalker) Next(ipos list.IWalkerPosition) list.IWalkerPosition {
 	pos := ipos.(list.ListPos)
-	length, err := dw.data.Length(dw.ctx)
-	if err != nil {
-		log.Fatalf("failed to get the length for data: %v", err)
-	}
-	if int(pos) == length {
+	if int(pos) == dw.getLength() {
 		return list.ListPos(-1)
 	} else {
 		return pos + 1
@@ -120,9 +167,5 @@ func (dw *DataWalker) Previous(ipos list.IWalkerPosition) list.IWalkerPosition {
 
 // Length implements the list.IBoundedWalker interface.
 func (dw *DataWalker) Length() int {
-	length, err := dw.data.Length(dw.ctx)
-	if err != nil {
-		log.Fatalf("failed to get the length for data: %v", err)
-	}
-	return length
+	return dw.getLength()
 }
@@ -0,0 +1,60 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/stretchr/testify/assert"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query"
+)
+
+var errFlaky = errors.New("flaky")
+
+// flakyData wraps a Data, failing every call while failing is set.
+type flakyData struct {
+	data.Data
+	failing bool
+}
+
+func (fd *flakyData) At(ctx context.Context, index int) (datum.Datum, error) {
+	if fd.failing {
+		return nil, errFlaky
+	}
+	return fd.Data.At(ctx, index)
+}
+
+func (fd *flakyData) Length(ctx context.Context) (int, error) {
+	if fd.failing {
+		return 0, errFlaky
+	}
+	return fd.Data.Length(ctx)
+}
+
+func TestDataWalkerSurvivesErrors(t *testing.T) {
+	fd := &flakyData{Data: data.NewMemoryData(testDatums, query.NewSubstringQueryExecutor())}
+	walker := data.NewDataWalker(fd)
+	errs := []error{}
+	walker.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	assert.Equal(t, numTestDatums, walker.Length())
+
+	fd.failing = true
+	// The walker should fall back to the last known length, and report the
+	// error only once, no matter how often it repeats.
+	assert.Equal(t, numTestDatums, walker.Length())
+	assert.Equal(t, list.ListPos(numTestDatums-1), walker.Last())
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errFlaky)
+
+	assert.Nil(t, walker.At(list.ListPos(0)))
+	assert.Len(t, errs, 2)
+
+	fd.failing = false
+	assert.NotNil(t, walker.At(list.ListPos(0)))
+}
@@ -2,6 +2,8 @@ package datum
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 )
 
 // Datum is a JSON object.
@@ -16,3 +18,49 @@ func (d Datum) String() string {
 
 	return string(jsonString)
 }
+
+// Copy returns a shallow copy of the datum. Stages that modify datums work on
+// copies, so that the source data is never mutated by a query.
+func (d Datum) Copy() Datum {
+	c := make(Datum, len(d))
+	for k, v := range d {
+		c[k] = v
+	}
+
+	return c
+}
+
+// Lookup returns the value at the path of fields, which may lead into nested
+// objects, and whether there is one.
+func (d Datum) Lookup(path []string) (interface{}, bool) {
+	var value interface{} = d
+	for _, field := range path {
+		var fields map[string]interface{}
+		switch v := value.(type) {
+		case Datum:
+			fields = v
+		case map[string]interface{}:
+			fields = v
+		default:
+			return nil, false
+		}
+
+		var ok bool
+		if value, ok = fields[field]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// ReadJSON reads a JSON array of objects from the given reader and returns
+// them as datums.
+func ReadJSON(r io.Reader) ([]Datum, error) {
+	datums := []Datum{}
+	if err := json.NewDecoder(r).Decode(&datums); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data into JSON: %w", err)
+	}
+
+	return datums, nil
+}
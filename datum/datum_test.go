@@ -0,0 +1,32 @@
+package datum_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utagai/look/datum"
+)
+
+func TestLookup(t *testing.T) {
+	d := datum.Datum{
+		"a": map[string]interface{}{"b": datum.Datum{"c": 1}},
+		"d": nil,
+		"e": "f",
+	}
+
+	for _, tc := range []struct {
+		path  []string
+		value interface{}
+		ok    bool
+	}{
+		{path: []string{"e"}, value: "f", ok: true},
+		{path: []string{"a", "b", "c"}, value: 1, ok: true},
+		{path: []string{"d"}, value: nil, ok: true},
+		{path: []string{"a", "x"}, ok: false},
+		{path: []string{"e", "f"}, ok: false},
+	} {
+		value, ok := d.Lookup(tc.path)
+		assert.Equal(t, tc.ok, ok, tc.path)
+		assert.Equal(t, tc.value, value, tc.path)
+	}
+}
@@ -0,0 +1,105 @@
+// Package diff finds the differences between two JSON values, field by field
+// and element by element.
+package diff
+
+import (
+	"sort"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze/execution"
+)
+
+// Kind is the kind of a change.
+type Kind string
+
+const (
+	// Added is a field or element that is only in the second value.
+	Added Kind = "added"
+	// Removed is a field or element that is only in the first value.
+	Removed Kind = "removed"
+	// Changed is a value that is in both, but differs.
+	Changed Kind = "changed"
+)
+
+// Change is a difference between two values.
+type Change struct {
+	// Path is the path of the difference from the root of the values, with a
+	// string for each key of an object and an int for each index of an array.
+	Path []interface{}
+	Kind Kind
+	// From is the value in the first value, unless it was added, and To the one
+	// in the second, unless it was removed.
+	From, To interface{}
+}
+
+// Diff returns the changes from the first value to the second, in the order of
+// their paths, with the keys of objects sorted. Objects and arrays are compared
+// recursively, and anything else is changed if it is of another type, or if
+// it doesn't compare as equal. Arrays are compared by index, so an element
+// inserted at the start changes every element after it.
+func Diff(from, to interface{}) []Change {
+	changes := []Change{}
+	diff(nil, datum.Normalize(from), datum.Normalize(to), &changes)
+	return changes
+}
+
+func diff(path []interface{}, from, to interface{}, changes *[]Change) {
+	fromType, toType := datum.TypeOf(from), datum.TypeOf(to)
+	switch {
+	case fromType != toType:
+		*changes = append(*changes, Change{Path: path, Kind: Changed, From: from, To: to})
+	case fromType == datum.ValueTypeObject:
+		diffObjects(path, from.(map[string]interface{}), to.(map[string]interface{}), changes)
+	case fromType == datum.ValueTypeArray:
+		diffArrays(path, from.([]interface{}), to.([]interface{}), changes)
+	case execution.Compare(from, to) != execution.Equal:
+		*changes = append(*changes, Change{Path: path, Kind: Changed, From: from, To: to})
+	}
+}
+
+func diffObjects(path []interface{}, from, to map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		keyPath := appendPath(path, key)
+		switch {
+		case !inFrom:
+			*changes = append(*changes, Change{Path: keyPath, Kind: Added, To: datum.Normalize(toValue)})
+		case !inTo:
+			*changes = append(*changes, Change{Path: keyPath, Kind: Removed, From: datum.Normalize(fromValue)})
+		default:
+			diff(keyPath, datum.Normalize(fromValue), datum.Normalize(toValue), changes)
+		}
+	}
+}
+
+func diffArrays(path []interface{}, from, to []interface{}, changes *[]Change) {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		indexPath := appendPath(path, i)
+		switch {
+		case i >= len(from):
+			*changes = append(*changes, Change{Path: indexPath, Kind: Added, To: datum.Normalize(to[i])})
+		case i >= len(to):
+			*changes = append(*changes, Change{Path: indexPath, Kind: Removed, From: datum.Normalize(from[i])})
+		default:
+			diff(indexPath, datum.Normalize(from[i]), datum.Normalize(to[i]), changes)
+		}
+	}
+}
+
+// appendPath returns the path with the step appended, without sharing the
+// backing array of the path, since sibling paths are appended to the same one.
+func appendPath(path []interface{}, step interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), step)
+}
@@ -0,0 +1,80 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/datum/diff"
+)
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		from, to interface{}
+		expected []diff.Change
+	}{
+		{
+			name:     "equal",
+			from:     datum.Datum{"a": float64(1), "b": []interface{}{"x", nil}},
+			to:       datum.Datum{"a": int64(1), "b": []interface{}{"x", nil}},
+			expected: []diff.Change{},
+		},
+		{
+			name: "numbers of other types",
+			from: datum.Datum{"a": uint(2), "b": int32(3)},
+			to:   datum.Datum{"a": float64(3), "b": float32(3)},
+			expected: []diff.Change{
+				{Path: []interface{}{"a"}, Kind: diff.Changed, From: uint(2), To: float64(3)},
+			},
+		},
+		{
+			name: "added, removed and changed fields",
+			from: datum.Datum{"a": float64(1), "b": "x"},
+			to:   datum.Datum{"a": float64(2), "c": true},
+			expected: []diff.Change{
+				{Path: []interface{}{"a"}, Kind: diff.Changed, From: float64(1), To: float64(2)},
+				{Path: []interface{}{"b"}, Kind: diff.Removed, From: "x"},
+				{Path: []interface{}{"c"}, Kind: diff.Added, To: true},
+			},
+		},
+		{
+			name: "nested objects",
+			from: datum.Datum{"req": datum.Datum{"status": float64(200), "path": "/"}},
+			to:   datum.Datum{"req": map[string]interface{}{"status": float64(500), "path": "/"}},
+			expected: []diff.Change{
+				{Path: []interface{}{"req", "status"}, Kind: diff.Changed, From: float64(200), To: float64(500)},
+			},
+		},
+		{
+			name: "arrays by index",
+			from: datum.Datum{"tags": []interface{}{"a", "b", "c"}},
+			to:   datum.Datum{"tags": []interface{}{"a", "x"}},
+			expected: []diff.Change{
+				{Path: []interface{}{"tags", 1}, Kind: diff.Changed, From: "b", To: "x"},
+				{Path: []interface{}{"tags", 2}, Kind: diff.Removed, From: "c"},
+			},
+		},
+		{
+			name: "other types are changes, even if they compare as equal",
+			from: datum.Datum{"n": "1", "o": datum.Datum{}},
+			to:   datum.Datum{"n": float64(1), "o": []interface{}{}},
+			expected: []diff.Change{
+				{Path: []interface{}{"n"}, Kind: diff.Changed, From: "1", To: float64(1)},
+				{Path: []interface{}{"o"}, Kind: diff.Changed, From: map[string]interface{}{}, To: []interface{}{}},
+			},
+		},
+		{
+			name: "null",
+			from: datum.Datum{"a": nil},
+			to:   datum.Datum{"a": float64(0)},
+			expected: []diff.Change{
+				{Path: []interface{}{"a"}, Kind: diff.Changed, From: nil, To: float64(0)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, diff.Diff(tc.from, tc.to))
+		})
+	}
+}
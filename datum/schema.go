@@ -0,0 +1,189 @@
+package datum
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// ValueType is the JSON type of a value.
+type ValueType string
+
+// The JSON types.
+const (
+	ValueTypeString ValueType = "string"
+	ValueTypeNumber ValueType = "number"
+	ValueTypeBool   ValueType = "bool"
+	ValueTypeNull   ValueType = "null"
+	ValueTypeObject ValueType = "object"
+	ValueTypeArray  ValueType = "array"
+)
+
+// Schema describes the fields of a set of datums.
+type Schema struct {
+	// Datums is the number of datums described.
+	Datums int
+	// Fields are the fields found in any of the datums, nested fields included,
+	// ordered by path. As with FieldSpans, fields of objects in arrays are left
+	// out.
+	Fields []*FieldSchema
+}
+
+// FieldSchema describes the values of a field across a set of datums.
+type FieldSchema struct {
+	// Path is the keys leading to the field, from the top-level one down.
+	Path []string
+	// Types is the number of datums in which the field has each type.
+	Types map[ValueType]int
+	// Missing is the number of datums without the field.
+	Missing int
+	// TopValues are the most common of the field's string, number, bool and
+	// null values, most common first.
+	TopValues []ValueCount
+}
+
+// ValueCount is a value, as JSON, and the number of datums it appears in.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// NewSchema returns the schema of the given datums, keeping up to topValues of
+// the most common values of each field.
+func NewSchema(datums []Datum, topValues int) *Schema {
+	b := schemaBuilder{fields: map[string]*fieldBuilder{}}
+	for _, d := range datums {
+		b.object(nil, map[string]interface{}(d))
+	}
+
+	schema := &Schema{Datums: len(datums), Fields: make([]*FieldSchema, 0, len(b.fields))}
+	for _, field := range b.fields {
+		present := 0
+		for _, count := range field.Types {
+			present += count
+		}
+		field.Missing = len(datums) - present
+		field.TopValues = topValueCounts(field.values, topValues)
+		schema.Fields = append(schema.Fields, &field.FieldSchema)
+	}
+	sort.Slice(schema.Fields, func(i, j int) bool {
+		return lessPath(schema.Fields[i].Path, schema.Fields[j].Path)
+	})
+
+	return schema
+}
+
+type fieldBuilder struct {
+	FieldSchema
+	// values counts the scalar values of the field, by their JSON.
+	values map[string]int
+}
+
+type schemaBuilder struct {
+	// fields are keyed by their path, with its keys separated by NULs, which
+	// are as good as never found in keys.
+	fields map[string]*fieldBuilder
+}
+
+func (b *schemaBuilder) object(path []string, obj map[string]interface{}) {
+	for key, value := range obj {
+		fieldPath := append(append([]string(nil), path...), key)
+		b.field(fieldPath, value)
+	}
+}
+
+func (b *schemaBuilder) field(path []string, value interface{}) {
+	value = Normalize(value)
+
+	id := strings.Join(path, "\x00")
+	field, ok := b.fields[id]
+	if !ok {
+		field = &fieldBuilder{
+			FieldSchema: FieldSchema{Path: path, Types: map[ValueType]int{}},
+			values:      map[string]int{},
+		}
+		b.fields[id] = field
+	}
+
+	valueType := TypeOf(value)
+	field.Types[valueType]++
+	switch valueType {
+	case ValueTypeObject:
+		b.object(path, value.(map[string]interface{}))
+	case ValueTypeArray:
+		// There is no path to the fields of objects in arrays, and arrays are
+		// too varied for their most common values to tell much.
+	default:
+		field.values[marshal(value)]++
+	}
+}
+
+// Normalize returns the value as one of the types that json.Unmarshal
+// produces, give or take the number types.
+func Normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Datum:
+		return map[string]interface{}(v)
+	case map[string]interface{}, []interface{}, nil, bool, string,
+		float64, float32, int, int32, int64, uint, uint32, uint64, json.Number:
+		return v
+	default:
+		// Stages may produce values of other types, e.g. slices of datums. Round
+		// trip these through JSON, as the tokenizer does.
+		var generic interface{}
+		if err := json.Unmarshal([]byte(marshal(v)), &generic); err != nil {
+			panic(err)
+		}
+		return generic
+	}
+}
+
+// TypeOf returns the JSON type of a normalized value.
+func TypeOf(value interface{}) ValueType {
+	switch value.(type) {
+	case map[string]interface{}:
+		return ValueTypeObject
+	case []interface{}:
+		return ValueTypeArray
+	case nil:
+		return ValueTypeNull
+	case bool:
+		return ValueTypeBool
+	case string:
+		return ValueTypeString
+	default:
+		return ValueTypeNumber
+	}
+}
+
+// topValueCounts returns the n most common values, most common first, and in
+// order of their JSON among the equally common.
+func topValueCounts(values map[string]int, n int) []ValueCount {
+	counts := make([]ValueCount, 0, len(values))
+	for value, count := range values {
+		counts = append(counts, ValueCount{Value: value, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+
+	return counts
+}
+
+// lessPath orders paths key by key, so that a field comes right before its
+// nested fields.
+func lessPath(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
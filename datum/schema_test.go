@@ -0,0 +1,68 @@
+package datum_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utagai/look/datum"
+)
+
+func TestSchema(t *testing.T) {
+	datums := []datum.Datum{
+		{"latency": float64(12), "host": "a", "tags": []interface{}{"x"}, "req": map[string]interface{}{"path": "/"}},
+		{"latency": float64(12), "host": "b", "req": datum.Datum{"path": "/", "user": nil}},
+		{"latency": "slow", "host": "a"},
+		{"latency": nil, "req": "none"},
+	}
+
+	schema := datum.NewSchema(datums, 2)
+	assert.Equal(t, 4, schema.Datums)
+
+	paths := make([][]string, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		paths = append(paths, field.Path)
+	}
+	require.Equal(t, [][]string{
+		{"host"},
+		{"latency"},
+		{"req"},
+		{"req", "path"},
+		{"req", "user"},
+		{"tags"},
+	}, paths)
+
+	assert.Equal(t, &datum.FieldSchema{
+		Path:      []string{"host"},
+		Types:     map[datum.ValueType]int{datum.ValueTypeString: 3},
+		Missing:   1,
+		TopValues: []datum.ValueCount{{Value: `"a"`, Count: 2}, {Value: `"b"`, Count: 1}},
+	}, schema.Fields[0])
+	assert.Equal(t, &datum.FieldSchema{
+		Path: []string{"latency"},
+		Types: map[datum.ValueType]int{
+			datum.ValueTypeNumber: 2,
+			datum.ValueTypeString: 1,
+			datum.ValueTypeNull:   1,
+		},
+		TopValues: []datum.ValueCount{{Value: "12", Count: 2}, {Value: `"slow"`, Count: 1}},
+	}, schema.Fields[1])
+	assert.Equal(t, &datum.FieldSchema{
+		Path:      []string{"req"},
+		Types:     map[datum.ValueType]int{datum.ValueTypeObject: 2, datum.ValueTypeString: 1},
+		Missing:   1,
+		TopValues: []datum.ValueCount{{Value: `"none"`, Count: 1}},
+	}, schema.Fields[2])
+	assert.Equal(t, &datum.FieldSchema{
+		Path:      []string{"req", "user"},
+		Types:     map[datum.ValueType]int{datum.ValueTypeNull: 1},
+		Missing:   3,
+		TopValues: []datum.ValueCount{{Value: "null", Count: 1}},
+	}, schema.Fields[4])
+	assert.Equal(t, &datum.FieldSchema{
+		Path:      []string{"tags"},
+		Types:     map[datum.ValueType]int{datum.ValueTypeArray: 1},
+		Missing:   3,
+		TopValues: []datum.ValueCount{},
+	}, schema.Fields[5])
+}
@@ -1,6 +1,7 @@
 package datum
 
 import (
+	"context"
 	"io"
 )
 
@@ -16,7 +17,13 @@ func NewSliceStream(datums []Datum) *SliceStream {
 	}
 }
 
-func (d *SliceStream) Next() (Datum, error) {
+// Next implements Stream. Since every query's data originates from a slice,
+// this is where a cancelled query notices that it should stop.
+func (d *SliceStream) Next(ctx context.Context) (Datum, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if d.i >= len(d.datums) {
 		return nil, io.EOF
 	}
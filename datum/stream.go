@@ -1,17 +1,21 @@
 package datum
 
 import (
+	"context"
 	"io"
 )
 
+// Stream is a stream of datums. Next returns io.EOF once the stream is
+// exhausted. Streams stop early with the context's error once the given
+// context is done.
 type Stream interface {
-	Next() (Datum, error)
+	Next(ctx context.Context) (Datum, error)
 }
 
-func StreamToSlice(stream Stream) ([]Datum, error) {
+func StreamToSlice(ctx context.Context, stream Stream) ([]Datum, error) {
 	datums := []Datum{}
 	for {
-		datum, err := stream.Next()
+		datum, err := stream.Next(ctx)
 		if err == io.EOF {
 			return datums, nil
 		} else if err != nil {
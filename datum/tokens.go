@@ -0,0 +1,183 @@
+package datum
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// TokenKind is the kind of a token of the JSON of a datum.
+type TokenKind int
+
+// The various token kinds. Punctuation covers everything that isn't a key or a
+// value, including whitespace.
+const (
+	TokenPunctuation TokenKind = iota
+	TokenKey
+	TokenString
+	TokenNumber
+	TokenBool
+	TokenNull
+)
+
+// Token is a piece of the JSON of a datum.
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// Tokens returns the JSON of the datum split into tokens, e.g. for syntax
+// highlighting. If indent is empty, the tokens add up to String(). Otherwise,
+// they add up to the JSON indented by indent, as json.MarshalIndent would.
+func (d Datum) Tokens(indent string) []Token {
+	t := tokenizer{indent: indent}
+	t.value(map[string]interface{}(d), 0)
+	return t.tokens
+}
+
+// Span is a range [Start, End) of the bytes of the JSON of a datum, as given by
+// Datum.String().
+type Span struct {
+	Start, End int
+}
+
+// FieldSpan is where a field lies in the JSON of a datum.
+type FieldSpan struct {
+	// Path is the keys leading to the field, from the top-level one down.
+	Path []string
+	// Key is the span of the field's quoted key, and Value that of its value.
+	Key, Value Span
+}
+
+// FieldSpans returns where each field of the datum lies in its JSON, nested
+// fields included. Fields of objects in arrays are left out, since there is no
+// path to them.
+func (d Datum) FieldSpans() []FieldSpan {
+	t := tokenizer{}
+	t.value(map[string]interface{}(d), 0)
+	return t.fields
+}
+
+type tokenizer struct {
+	indent string
+	tokens []Token
+	// offset is the number of bytes emitted so far.
+	offset int
+	// path is the keys leading to the current value, and arrays the number of
+	// arrays it is in.
+	path   []string
+	arrays int
+	fields []FieldSpan
+}
+
+func (t *tokenizer) emit(kind TokenKind, text string) {
+	t.offset += len(text)
+	// Merge runs of punctuation, so that there are fewer tokens to style.
+	if n := len(t.tokens); n > 0 && kind == TokenPunctuation && t.tokens[n-1].Kind == TokenPunctuation {
+		t.tokens[n-1].Text += text
+		return
+	}
+
+	t.tokens = append(t.tokens, Token{Kind: kind, Text: text})
+}
+
+// newline starts a new line at the given depth, if indenting.
+func (t *tokenizer) newline(depth int) {
+	if t.indent != "" {
+		t.emit(TokenPunctuation, "\n"+strings.Repeat(t.indent, depth))
+	}
+}
+
+func (t *tokenizer) value(v interface{}, depth int) {
+	switch v := v.(type) {
+	case Datum:
+		t.value(map[string]interface{}(v), depth)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			t.emit(TokenPunctuation, "{}")
+			return
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// This matches the order json.Marshal uses.
+		sort.Strings(keys)
+
+		t.emit(TokenPunctuation, "{")
+		for i, key := range keys {
+			if i > 0 {
+				t.emit(TokenPunctuation, ",")
+			}
+			t.newline(depth + 1)
+			keyStart := t.offset
+			t.emit(TokenKey, marshal(key))
+			keyEnd := t.offset
+			if t.indent != "" {
+				t.emit(TokenPunctuation, ": ")
+			} else {
+				t.emit(TokenPunctuation, ":")
+			}
+
+			t.path = append(t.path, key)
+			valueStart := t.offset
+			t.value(v[key], depth+1)
+			if t.arrays == 0 {
+				t.fields = append(t.fields, FieldSpan{
+					Path:  append([]string(nil), t.path...),
+					Key:   Span{Start: keyStart, End: keyEnd},
+					Value: Span{Start: valueStart, End: t.offset},
+				})
+			}
+			t.path = t.path[:len(t.path)-1]
+		}
+		t.newline(depth)
+		t.emit(TokenPunctuation, "}")
+	case []interface{}:
+		if len(v) == 0 {
+			t.emit(TokenPunctuation, "[]")
+			return
+		}
+
+		t.arrays++
+		defer func() { t.arrays-- }()
+
+		t.emit(TokenPunctuation, "[")
+		for i, elem := range v {
+			if i > 0 {
+				t.emit(TokenPunctuation, ",")
+			}
+			t.newline(depth + 1)
+			t.value(elem, depth+1)
+		}
+		t.newline(depth)
+		t.emit(TokenPunctuation, "]")
+	case nil:
+		t.emit(TokenNull, "null")
+	case bool:
+		t.emit(TokenBool, marshal(v))
+	case string:
+		t.emit(TokenString, marshal(v))
+	case float64, float32, int, int32, int64, uint, uint32, uint64, json.Number:
+		t.emit(TokenNumber, marshal(v))
+	default:
+		// Stages may produce values of other types, e.g. slices of datums. Round
+		// trip these through JSON, so that they are tokenized like any other value.
+		var generic interface{}
+		if err := json.Unmarshal([]byte(marshal(v)), &generic); err != nil {
+			panic(err)
+		}
+		t.value(generic, depth)
+	}
+}
+
+func marshal(v interface{}) string {
+	jsonString, err := json.Marshal(v)
+	if err != nil {
+		// Datums are always created from JSON initially, so this should never error.
+		panic(err)
+	}
+
+	return string(jsonString)
+}
@@ -0,0 +1,80 @@
+package datum_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utagai/look/datum"
+)
+
+func join(tokens []datum.Token) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString(token.Text)
+	}
+
+	return sb.String()
+}
+
+func TestTokens(t *testing.T) {
+	d := datum.Datum{
+		"a": float64(1.5),
+		"b": "hello <world>",
+		"c": []interface{}{true, nil, map[string]interface{}{}},
+		"d": map[string]interface{}{"e": []interface{}{}},
+		"f": []datum.Datum{{"g": int64(2)}},
+	}
+
+	t.Run("compact", func(t *testing.T) {
+		assert.Equal(t, d.String(), join(d.Tokens("")))
+	})
+
+	t.Run("indented", func(t *testing.T) {
+		expected, err := json.MarshalIndent(d, "", "  ")
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), join(d.Tokens("  ")))
+	})
+
+	t.Run("kinds", func(t *testing.T) {
+		d := datum.Datum{"a": []interface{}{"s", float64(1), false, nil}}
+		assert.Equal(t, []datum.Token{
+			{Kind: datum.TokenPunctuation, Text: "{"},
+			{Kind: datum.TokenKey, Text: `"a"`},
+			{Kind: datum.TokenPunctuation, Text: ":["},
+			{Kind: datum.TokenString, Text: `"s"`},
+			{Kind: datum.TokenPunctuation, Text: ","},
+			{Kind: datum.TokenNumber, Text: "1"},
+			{Kind: datum.TokenPunctuation, Text: ","},
+			{Kind: datum.TokenBool, Text: "false"},
+			{Kind: datum.TokenPunctuation, Text: ","},
+			{Kind: datum.TokenNull, Text: "null"},
+			{Kind: datum.TokenPunctuation, Text: "]}"},
+		}, d.Tokens(""))
+	})
+}
+
+func TestFieldSpans(t *testing.T) {
+	d := datum.Datum{
+		"a": map[string]interface{}{"b": "c"},
+		"d": []interface{}{map[string]interface{}{"e": float64(1)}},
+	}
+	s := d.String()
+
+	spans := d.FieldSpans()
+	text := make(map[string][2]string, len(spans))
+	for _, span := range spans {
+		text[strings.Join(span.Path, ".")] = [2]string{
+			s[span.Key.Start:span.Key.End],
+			s[span.Value.Start:span.Value.End],
+		}
+	}
+
+	assert.Equal(t, map[string][2]string{
+		"a":   {`"a"`, `{"b":"c"}`},
+		"a.b": {`"b"`, `"c"`},
+		"d":   {`"d"`, `[{"e":1}]`},
+	}, text)
+}
@@ -1,6 +1,7 @@
 package datum
 
 import (
+	"context"
 	"io"
 )
 
@@ -20,7 +21,7 @@ func NewUnaryStream(datum Datum) *UnaryStream {
 }
 
 // Next implements DatumStream.
-func (d *UnaryStream) Next() (Datum, error) {
+func (d *UnaryStream) Next(_ context.Context) (Datum, error) {
 	if d.datumReturned {
 		return nil, io.EOF
 	}
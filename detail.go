@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gcla/gowid/widgets/tree"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/datum"
+)
+
+const detailHint = "Enter folds, e expands all, c collapses all, y copies the path, v the value, ESC closes."
+
+// datumNode is a node of the tree of a datum. Objects and arrays are
+// collapsible, and their fields or elements are their children.
+type datumNode struct {
+	*tree.Collapsible
+	// path is the jq-style path of the node from the root of the datum.
+	path string
+	// value is the value of the node, e.g. the whole object for an object.
+	value interface{}
+	// container is whether the node is an object or an array.
+	container bool
+}
+
+var _ tree.ICollapsible = (*datumNode)(nil)
+
+// newDatumTree returns the tree of the given datum, fully expanded.
+func newDatumTree(d datum.Datum) *datumNode {
+	return newDatumNode("", ".", map[string]interface{}(d))
+}
+
+func newDatumNode(label, path string, value interface{}) *datumNode {
+	var children []tree.IModel
+	var summary string
+	container := true
+	switch v := value.(type) {
+	case datum.Datum:
+		return newDatumNode(label, path, map[string]interface{}(v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// This matches the order of the fields in datum.Datum.String().
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			children = append(children, newDatumNode(key, joinPath(path, key), v[key]))
+		}
+		summary = fmt.Sprintf("{…} %s", plural(len(v), "field"))
+	case []interface{}:
+		for i, elem := range v {
+			children = append(children, newDatumNode(fmt.Sprintf("[%d]", i), fmt.Sprintf("%s[%d]", path, i), elem))
+		}
+		summary = fmt.Sprintf("[…] %s", plural(len(v), "item"))
+	default:
+		container = false
+		jsonValue, err := json.Marshal(v)
+		if err != nil {
+			summary = fmt.Sprintf("%v", v)
+		} else {
+			summary = string(jsonValue)
+		}
+	}
+
+	leaf := summary
+	if label != "" {
+		leaf = fmt.Sprintf("%s: %s", label, summary)
+	}
+
+	return &datumNode{
+		Collapsible: tree.NewCollapsible(leaf, children),
+		path:        path,
+		value:       value,
+		container:   container,
+	}
+}
+
+// plural returns the count of the given noun, e.g. "1 field" or "2 fields".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+var simpleKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// joinPath returns the path of the given key of the object at the given path.
+// Keys that can't be written bare are quoted, as jq does.
+func joinPath(path, key string) string {
+	if !simpleKeyRegex.MatchString(key) {
+		key = fmt.Sprintf("[%s]", strconv.Quote(key))
+	}
+
+	if path == "." {
+		return "." + key
+	}
+
+	return path + "." + key
+}
+
+// setCollapsedRecursively collapses or expands the node and all of its
+// descendants. Only the descendants are changed if skipSelf is set.
+func (n *datumNode) setCollapsedRecursively(app gowid.IApp, collapsed, skipSelf bool) {
+	if !skipSelf && n.container {
+		n.SetCollapsed(app, collapsed)
+	}
+
+	for _, child := range n.GetChildren() {
+		child.(*datumNode).setCollapsedRecursively(app, collapsed, false)
+	}
+}
+
+// detailView is a modal that shows a single datum as a tree whose objects and
+// arrays can be folded.
+type detailView struct {
+	gowid.IWidget
+	root    *datumNode
+	walker  *tree.TreeWalker
+	hint    *text.Widget
+	onClose func(app gowid.IApp)
+}
+
+func newDetailView(title string, d datum.Datum, onClose func(gowid.IApp)) *detailView {
+	root := newDatumTree(d)
+	walker := tree.NewWalker(
+		root,
+		tree.NewPos(),
+		tree.WidgetMakerFunction(makeDatumNodeWidget),
+		tree.DecoratorFunction(decorateDatumNode),
+	)
+	hint := text.New(detailHint)
+
+	view := pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: tree.New(walker),
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: styled.New(hint, gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+	})
+
+	return &detailView{
+		IWidget: framed.New(view, framed.Options{
+			Frame: framed.UnicodeFrame,
+			Title: title,
+		}),
+		root:    root,
+		walker:  walker,
+		hint:    hint,
+		onClose: onClose,
+	}
+}
+
+// focusedNode returns the node of the tree that is in focus.
+func (v *detailView) focusedNode() *datumNode {
+	pos := v.walker.Focus().(tree.IPos)
+	return pos.GetSubStructure(v.root).(*datumNode)
+}
+
+// UserInput implements the gowid.IWidget interface. The view is a modal, so it
+// swallows every key, rather than letting them through to the widgets beneath.
+func (v *detailView) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	switch {
+	case evk.Key() == tcell.KeyEsc || evk.Rune() == 'q':
+		v.onClose(app)
+	case evk.Key() == tcell.KeyEnter || evk.Rune() == ' ':
+		if node := v.focusedNode(); node.container {
+			node.SetCollapsed(app, !node.IsCollapsed())
+		}
+	case evk.Rune() == 'e':
+		v.root.setCollapsedRecursively(app, false, false)
+	case evk.Rune() == 'c':
+		// Keep the root open, so there is something left to look at. The focus
+		// may well have been hidden, so move it to the root.
+		v.root.setCollapsedRecursively(app, true, true)
+		v.walker.SetFocus(tree.NewPos(), app)
+	case evk.Rune() == 'y':
+		path := v.focusedNode().path
+		if err := copyToClipboard(path); err != nil {
+			v.hint.SetText(fmt.Sprintf("Failed to copy %s: %v", path, err), app)
+		} else {
+			v.hint.SetText(fmt.Sprintf("Copied %s to the clipboard.", path), app)
+		}
+		return true
+	case evk.Rune() == 'v':
+		node := v.focusedNode()
+		if err := copyToClipboard(fieldValue(node.value)); err != nil {
+			v.hint.SetText(fmt.Sprintf("Failed to copy the value of %s: %v", node.path, err), app)
+		} else {
+			v.hint.SetText(fmt.Sprintf("Copied the value of %s to the clipboard.", node.path), app)
+		}
+		return true
+	default:
+		v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	v.hint.SetText(detailHint, app)
+	return true
+}
+
+func makeDatumNodeWidget(pos tree.IPos, model tree.IModel) gowid.IWidget {
+	return selectable.New(
+		styled.NewExt(
+			text.New(model.Leaf()),
+			gowid.MakePaletteRef("modal"), gowid.MakePaletteRef("fmodal"),
+		),
+	)
+}
+
+// decorateDatumNode indents the node by its depth, and marks whether it is
+// folded if it can be.
+func decorateDatumNode(pos tree.IPos, model tree.IModel, maker tree.IWidgetMaker) gowid.IWidget {
+	depth := len(pos.Indices())
+	marker := "  "
+	if node := model.(*datumNode); node.container {
+		marker = "▾ "
+		if node.IsCollapsed() {
+			marker = "▸ "
+		}
+	}
+	prefix := strings.Repeat("  ", depth) + marker
+
+	return columns.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: text.New(prefix),
+			D:       gowid.RenderWithUnits{U: len([]rune(prefix))},
+		},
+		&gowid.ContainerWidget{
+			IWidget: maker.MakeWidget(pos, model),
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+	})
+}
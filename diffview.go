@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/datum/diff"
+)
+
+const diffHint = "+ added, - removed, ~ changed. ESC closes."
+
+// diffMarkers mark each kind of change, so that the diff reads without colors.
+var diffMarkers = map[diff.Kind]string{
+	diff.Added:   "+",
+	diff.Removed: "-",
+	diff.Changed: "~",
+}
+
+// diffView is a modal that shows the changes from one datum to another, a line
+// per field or element that was added, removed or changed.
+type diffView struct {
+	gowid.IWidget
+	onClose func(app gowid.IApp)
+}
+
+func newDiffView(title string, from, to datum.Datum, onClose func(gowid.IApp)) *diffView {
+	changes := diff.Diff(from, to)
+	widgets := make([]gowid.IWidget, 0, len(changes))
+	for _, change := range changes {
+		widgets = append(widgets, selectable.New(
+			styled.NewExt(
+				text.New(describeChange(change)),
+				gowid.MakePaletteRef(string(change.Kind)), gowid.MakePaletteRef("fmodal"),
+			),
+		))
+	}
+
+	var body gowid.IWidget = list.New(list.NewSimpleListWalker(widgets))
+	if len(changes) == 0 {
+		body = text.New("The results are the same.")
+	}
+
+	view := pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: body,
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: styled.New(text.New(diffHint), gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+	})
+
+	return &diffView{
+		IWidget: framed.New(view, framed.Options{
+			Frame: framed.UnicodeFrame,
+			Title: title,
+		}),
+		onClose: onClose,
+	}
+}
+
+// describeChange describes the change, e.g.
+//
+//	~ .req.status: 200 → 500
+func describeChange(change diff.Change) string {
+	path := changePath(change.Path)
+	switch change.Kind {
+	case diff.Added:
+		return fmt.Sprintf("%s %s: %s", diffMarkers[change.Kind], path, compactJSON(change.To))
+	case diff.Removed:
+		return fmt.Sprintf("%s %s: %s", diffMarkers[change.Kind], path, compactJSON(change.From))
+	default:
+		return fmt.Sprintf("%s %s: %s → %s", diffMarkers[change.Kind], path, compactJSON(change.From), compactJSON(change.To))
+	}
+}
+
+// changePath returns the jq-style path of the change, as the details show it.
+func changePath(steps []interface{}) string {
+	path := "."
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			path = joinPath(path, s)
+		case int:
+			path = fmt.Sprintf("%s[%d]", path, s)
+		}
+	}
+
+	return path
+}
+
+func compactJSON(value interface{}) string {
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(jsonValue)
+}
+
+// UserInput implements the gowid.IWidget interface. The view is a modal, so it
+// swallows every key, rather than letting them through to the widgets beneath.
+func (v *diffView) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	if evk.Key() == tcell.KeyEsc || evk.Rune() == 'q' {
+		v.onClose(app)
+	} else {
+		v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	return true
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+)
+
+// finder moves the focus of the results, either to the next datum whose JSON
+// matches a pattern, like a pager's / and ?, or to an index, like :123. The
+// pattern or index is typed at the prompt.
+type finder struct {
+	results *resultsView
+	prompt  *prompt
+	// setHint reports on the finder in the status bar.
+	setHint func(app gowid.IApp, hint string)
+
+	// pattern is the pattern last searched for, which n and N search for again,
+	// and backward whether it was searched for with ?.
+	pattern  *regexp.Regexp
+	backward bool
+	// cancel cancels the search in progress, if any.
+	cancel context.CancelFunc
+}
+
+func newFinder(results *resultsView, prompt *prompt, setHint func(gowid.IApp, string)) *finder {
+	return &finder{results: results, prompt: prompt, setHint: setHint}
+}
+
+// HandleKey handles the keys that start a prompt or repeat the last search,
+// and returns whether it did. It is meant for when the results are in focus,
+// since the keys are otherwise typed into the query.
+func (f *finder) HandleKey(app gowid.IApp, ev *tcell.EventKey) bool {
+	if ev.Key() != tcell.KeyRune {
+		return false
+	}
+
+	switch r := ev.Rune(); r {
+	case '/', '?':
+		f.prompt.Start(app, string(r), func(app gowid.IApp, pattern string) {
+			f.submit(app, pattern, r == '?')
+		})
+	case ':':
+		f.prompt.Start(app, ":", f.jump)
+	case 'n':
+		f.repeat(app, f.backward)
+	case 'N':
+		f.repeat(app, !f.backward)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Cancel cancels the search in progress, and returns whether there was one.
+func (f *finder) Cancel(app gowid.IApp) bool {
+	if f.cancel == nil {
+		return false
+	}
+
+	f.cancel()
+	f.cancel = nil
+	f.setHint(app, "Search cancelled.")
+	return true
+}
+
+func (f *finder) submit(app gowid.IApp, arg string, backward bool) {
+	if arg == "" {
+		// Like a pager, an empty pattern searches for the last one again.
+		f.repeat(app, backward)
+		return
+	}
+	pattern, err := regexp.Compile(arg)
+	if err != nil {
+		f.setHint(app, fmt.Sprintf("Invalid pattern: %v", err))
+		return
+	}
+	f.pattern = pattern
+	f.backward = backward
+	f.search(app, backward)
+}
+
+// jump moves the focus to the datum at the index, which counts from 0, as the
+// details of datums do.
+func (f *finder) jump(app gowid.IApp, arg string) {
+	index, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || index < 0 {
+		f.setHint(app, fmt.Sprintf("Invalid index %q.", arg))
+		return
+	}
+
+	walker := f.results.Walker()
+	length, err := walker.Data().Length(context.Background())
+	if err != nil {
+		f.setHint(app, fmt.Sprintf("Failed to jump: %v", err))
+		return
+	}
+	if index >= length {
+		f.setHint(app, fmt.Sprintf("There are only %s.", plural(length, "result")))
+		return
+	}
+
+	walker.SetFocus(list.ListPos(index), app)
+	f.setHint(app, "")
+}
+
+// repeat searches for the last pattern again.
+func (f *finder) repeat(app gowid.IApp, backward bool) {
+	if f.pattern == nil {
+		f.setHint(app, "There is no previous search.")
+		return
+	}
+
+	f.search(app, backward)
+}
+
+// search moves the focus to the next datum after it that matches the pattern,
+// or before it if backward is set. The datums are searched in the background,
+// since there may be many of them, and Esc cancels the search.
+func (f *finder) search(app gowid.IApp, backward bool) {
+	if f.cancel != nil {
+		f.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	walker := f.results.Walker()
+	d := walker.Data()
+	from := int(walker.Focus().(list.ListPos))
+	pattern := f.pattern
+	f.setHint(app, fmt.Sprintf("Searching for /%s/… ESC cancels", pattern))
+
+	go func() {
+		index, err := data.Search(ctx, d, from, backward, func(candidate datum.Datum) bool {
+			return pattern.MatchString(candidate.String())
+		})
+
+		_ = app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			// The search may have been cancelled, or superseded by another.
+			if ctx.Err() != nil {
+				return
+			}
+			f.cancel = nil
+			cancel()
+			// The results may have changed since, in which case the index means
+			// nothing.
+			if f.results.Walker().Data() != d {
+				return
+			}
+
+			switch {
+			case err != nil:
+				f.setHint(app, fmt.Sprintf("Failed to search: %v", err))
+			case index == -1:
+				f.setHint(app, fmt.Sprintf("Nothing matches /%s/.", pattern))
+			default:
+				f.results.Walker().SetFocus(list.ListPos(index), app)
+				hint := fmt.Sprintf("/%s/ matches result #%d.", pattern, index)
+				wrapped := index <= from
+				if backward {
+					wrapped = index >= from
+				}
+				if wrapped {
+					hint += " The search wrapped around."
+				}
+				f.setHint(app, hint)
+			}
+		}))
+	}()
+}
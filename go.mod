@@ -4,14 +4,16 @@ go 1.19
 
 require (
 	github.com/gcla/gowid v1.2.0
+	github.com/gdamore/tcell v1.3.1-0.20200115030318-bff4943f9a29
 	github.com/stretchr/testify v1.7.0
 	go.mongodb.org/mongo-driver v1.11.1
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/gdamore/tcell v1.3.1-0.20200115030318-bff4943f9a29 // indirect
 	github.com/golang/snappy v0.0.1 // indirect
 	github.com/hashicorp/golang-lru v0.5.1 // indirect
 	github.com/klauspost/compress v1.13.6 // indirect
@@ -28,7 +30,5 @@ require (
 	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
 	golang.org/x/crypto v0.0.0-20220622213112-05595931fe9d // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
-	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
 	golang.org/x/text v0.3.7 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/hpadding"
+	"github.com/gcla/gowid/widgets/palettemap"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/utagai/look/datum"
+)
+
+// prettyIndent is the indentation of pretty-printed datums.
+const prettyIndent = "  "
+
+// tokenPaletteNames are the names of the palette entries of each kind of JSON
+// token.
+var tokenPaletteNames = map[datum.TokenKind]string{
+	datum.TokenPunctuation: "jpunct",
+	datum.TokenKey:         "jkey",
+	datum.TokenString:      "jstring",
+	datum.TokenNumber:      "jnumber",
+	datum.TokenBool:        "jbool",
+	datum.TokenNull:        "jnull",
+}
+
+// focused returns the name of the palette entry used instead of the given one
+// when in focus.
+func focused(name string) string {
+	return "f" + name
+}
+
+// matchPaletteName is the name of the palette entry of the parts of a result
+// that matched the query.
+const matchPaletteName = "match"
+
+// focusMap maps each palette entry of a result to its focused counterpart.
+var focusMap = func() palettemap.Map {
+	m := palettemap.Map{"body": focused("body"), matchPaletteName: focused(matchPaletteName)}
+	for _, name := range tokenPaletteNames {
+		m[name] = focused(name)
+	}
+
+	return m
+}()
+
+// newDatumRenderer returns a function that renders datums as syntax
+// highlighted JSON, for the data walker. Datums are shown on a single line,
+// unless pretty is set, in which case they are indented over several lines.
+// The parts of each datum that matches returns are highlighted, if it is set.
+func newDatumRenderer(pretty bool, matches func(datum.Datum) []datum.Span) func(datum.Datum) gowid.IWidget {
+	indent := ""
+	if pretty {
+		indent = prettyIndent
+	}
+
+	return func(d datum.Datum) gowid.IWidget {
+		var spans []datum.Span
+		if matches != nil {
+			spans = matches(d)
+		}
+		segments := highlightTokens(d.Tokens(indent), spans)
+
+		return selectable.New(
+			palettemap.New(
+				hpadding.New(
+					text.NewFromContent(text.NewContent(segments)),
+					gowid.HAlignRight{}, gowid.RenderFlow{},
+				),
+				focusMap,
+				palettemap.Map{},
+			),
+		)
+	}
+}
+
+// highlightTokens returns the styled segments of the tokens, with the given
+// spans highlighted as matches.
+func highlightTokens(tokens []datum.Token, spans []datum.Span) []text.ContentSegment {
+	segments := make([]text.ContentSegment, 0, len(tokens))
+	// offset is the offset into the unindented JSON, which is what the spans
+	// refer to. The two only differ in the whitespace of the punctuation, which
+	// the unindented JSON has none of.
+	offset := 0
+	for _, token := range tokens {
+		style := tokenPaletteNames[token.Kind]
+		// Split the token into runs that are either all matched or all not.
+		start := 0
+		startMatched := false
+		for i := 0; i < len(token.Text); i++ {
+			var matched bool
+			if token.Kind == datum.TokenPunctuation && isSpace(token.Text[i]) {
+				// Indentation within a match, e.g. after the colon of a matched
+				// field, is part of it.
+				matched = offset > 0 && inSpans(spans, offset-1) && inSpans(spans, offset)
+			} else {
+				matched = inSpans(spans, offset)
+				offset++
+			}
+
+			if i > start && matched != startMatched {
+				segments = append(segments, styledRun(token.Text[start:i], style, startMatched))
+				start = i
+			}
+			if i == start {
+				startMatched = matched
+			}
+		}
+		segments = append(segments, styledRun(token.Text[start:], style, startMatched))
+	}
+
+	return segments
+}
+
+func styledRun(run, style string, matched bool) text.ContentSegment {
+	if matched {
+		style = matchPaletteName
+	}
+
+	return text.StyledContent(run, gowid.MakePaletteRef(style))
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\n'
+}
+
+func inSpans(spans []datum.Span, offset int) bool {
+	for _, span := range spans {
+		if offset >= span.Start && offset < span.End {
+			return true
+		}
+	}
+
+	return false
+}
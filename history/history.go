@@ -0,0 +1,244 @@
+// Package history persists the queries run in look, so that they can be
+// recalled in later sessions.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MaxEntries is the number of entries kept in the history. The oldest entries
+// are dropped beyond it.
+const MaxEntries = 1000
+
+// compactAt is the number of lines at which the history file is compacted when
+// it is loaded. Entries are appended to the file, so that sessions running at
+// the same time don't overwrite each other's, which leaves the file with the
+// earlier entries of queries that were run again.
+const compactAt = 2 * MaxEntries
+
+// Entry is a query in the history.
+type Entry struct {
+	Query string `json:"query"`
+	// Source is the source the query was run against.
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// History is the history of queries, stored as a file of JSON lines, one per
+// entry, oldest first. Several sessions may share the file: each appends its
+// entries to it, under a lock.
+type History struct {
+	path    string
+	entries []Entry
+}
+
+// DefaultPath returns the path of the history file in the user's config
+// directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "look", "history.jsonl"), nil
+}
+
+// Load loads the history stored at the given path. A missing file is an empty
+// history. The file is compacted if it has grown too long.
+func Load(path string) (*History, error) {
+	h := &History{path: path}
+
+	entries, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) >= compactAt {
+		if err := h.compact(); err != nil {
+			return nil, err
+		}
+		// The file may have gained entries since it was read.
+		if entries, err = h.read(); err != nil {
+			return nil, err
+		}
+	}
+	h.entries = compacted(entries)
+
+	return h, nil
+}
+
+// Add adds the query, run against the given source at the given time, and
+// appends it to the history file. An earlier entry for the same query and
+// source is dropped, so that each appears once, at the time it was last run.
+func (h *History) Add(query, source string, t time.Time) error {
+	entry := Entry{Query: query, Source: source, Time: t}
+	h.entries = compacted(append(h.entries, entry))
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to write the history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create the history directory: %w", err)
+	}
+	unlock, err := h.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the history (%q): %w", h.path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write the history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write the history: %w", err)
+	}
+
+	return nil
+}
+
+// Queries returns the queries in the history, oldest first. Only the queries
+// run against the given source are returned, unless it is empty. Either way,
+// each query appears once, at the time it was last run.
+func (h *History) Queries(source string) []string {
+	queries := []string{}
+	seen := map[string]bool{}
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if (source != "" && entry.Source != source) || seen[entry.Query] {
+			continue
+		}
+		seen[entry.Query] = true
+		queries = append(queries, entry.Query)
+	}
+
+	// We collected them newest first.
+	for i, j := 0, len(queries)-1; i < j; i, j = i+1, j-1 {
+		queries[i], queries[j] = queries[j], queries[i]
+	}
+
+	return queries
+}
+
+// compacted returns the entries without the earlier entries of the same query
+// and source, and without the oldest entries beyond MaxEntries.
+func compacted(entries []Entry) []Entry {
+	type key struct{ query, source string }
+	latest := make(map[key]int, len(entries))
+	for i, entry := range entries {
+		latest[key{entry.Query, entry.Source}] = i
+	}
+
+	kept := make([]Entry, 0, len(latest))
+	for i, entry := range entries {
+		if latest[key{entry.Query, entry.Source}] == i {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) > MaxEntries {
+		kept = kept[len(kept)-MaxEntries:]
+	}
+
+	return kept
+}
+
+// read reads the entries of the history file, as they are in it.
+func (h *History) read() ([]Entry, error) {
+	f, err := os.Open(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open the history (%q): %w", h.path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse the history (%q): %w", h.path, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the history (%q): %w", h.path, err)
+	}
+
+	return entries, nil
+}
+
+// lock locks the history file against other sessions, until the returned
+// function is called. The lock is held on a file of its own, since compacting
+// replaces the history file.
+func (h *History) lock() (func(), error) {
+	f, err := os.OpenFile(h.path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the history: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock the history: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// compact rewrites the history file with only its compacted entries. It
+// writes to a temporary file first and renames it over the history, so that a
+// failure never leaves the history half-written, and it holds the lock
+// throughout, so that no entry appended meanwhile is lost.
+func (h *History) compact() error {
+	unlock, err := h.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.read()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary history file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for _, entry := range compacted(entries) {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write the history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the history: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return fmt.Errorf("failed to replace the history (%q): %w", h.path, err)
+	}
+
+	return nil
+}
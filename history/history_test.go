@@ -0,0 +1,99 @@
+package history_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utagai/look/history"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "look", "history.jsonl")
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h, err := history.Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, h.Queries(""))
+
+	for i, add := range []struct{ query, source string }{
+		{"filter .a = 1", "a.json"},
+		{"sort .b", "b.json"},
+		{"filter .a = 1", "a.json"},
+		{"sort .b", "a.json"},
+	} {
+		require.NoError(t, h.Add(add.query, add.source, start.Add(time.Duration(i)*time.Minute)))
+	}
+
+	// The history should survive being reloaded.
+	h, err = history.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"filter .a = 1", "sort .b"}, h.Queries(""))
+	assert.Equal(t, []string{"filter .a = 1", "sort .b"}, h.Queries("a.json"))
+	assert.Equal(t, []string{"sort .b"}, h.Queries("b.json"))
+
+	// Entries are appended, and only compacted once the file grows long.
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		`{"query":"filter .a = 1","source":"a.json","time":"2021-01-01T00:00:00Z"}`+"\n"+
+		`{"query":"sort .b","source":"b.json","time":"2021-01-01T00:01:00Z"}`+"\n"+
+		`{"query":"filter .a = 1","source":"a.json","time":"2021-01-01T00:02:00Z"}`+"\n"+
+		`{"query":"sort .b","source":"a.json","time":"2021-01-01T00:03:00Z"}`+"\n",
+		string(contents),
+	)
+}
+
+func TestHistoryDropsOldestEntries(t *testing.T) {
+	h, err := history.Load(filepath.Join(t.TempDir(), "history.jsonl"))
+	require.NoError(t, err)
+
+	for i := 0; i < history.MaxEntries+1; i++ {
+		require.NoError(t, h.Add(time.Duration(i).String(), "", time.Now()))
+	}
+
+	queries := h.Queries("")
+	assert.Len(t, queries, history.MaxEntries)
+	assert.Equal(t, time.Duration(1).String(), queries[0])
+}
+
+func TestHistoryConcurrentSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	a, err := history.Load(path)
+	require.NoError(t, err)
+	b, err := history.Load(path)
+	require.NoError(t, err)
+
+	// Neither session's entries should overwrite the other's.
+	require.NoError(t, a.Add("sort .a", "", time.Now()))
+	require.NoError(t, b.Add("sort .b", "", time.Now()))
+	require.NoError(t, a.Add("sort .c", "", time.Now()))
+
+	h, err := history.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sort .a", "sort .b", "sort .c"}, h.Queries(""))
+}
+
+func TestHistoryCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := history.Load(path)
+	require.NoError(t, err)
+
+	// Rerunning the same few queries grows the file, but not the history.
+	for i := 0; i < 2*history.MaxEntries; i++ {
+		require.NoError(t, h.Add(fmt.Sprintf("sort .%d", i%3), "", time.Now()))
+	}
+
+	h, err = history.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sort .2", "sort .0", "sort .1"}, h.Queries(""))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(contents), "\n"))
+}
@@ -0,0 +1,54 @@
+// Package generics has the small generic helpers that the standard library
+// doesn't have as of the Go version look targets.
+package generics
+
+// Ordered are the types that support <.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Min returns the smaller of a and b.
+func Min[T Ordered](a, b T) T {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Max returns the larger of a and b.
+func Max[T Ordered](a, b T) T {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// Contains returns whether v is in s.
+func Contains[T comparable](s []T, v T) bool {
+	for _, elem := range s {
+		if elem == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equal returns whether a and b have the same elements, in the same order.
+func Equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
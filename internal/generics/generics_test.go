@@ -0,0 +1,29 @@
+package generics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utagai/look/internal/generics"
+)
+
+func TestMinMax(t *testing.T) {
+	assert.Equal(t, 1, generics.Min(1, 2))
+	assert.Equal(t, 1, generics.Min(2, 1))
+	assert.Equal(t, 2, generics.Max(1, 2))
+	assert.Equal(t, 2, generics.Max(2, 1))
+	assert.Equal(t, "a", generics.Min("b", "a"))
+}
+
+func TestContains(t *testing.T) {
+	assert.True(t, generics.Contains([]string{"a", "b"}, "b"))
+	assert.False(t, generics.Contains([]string{"a", "b"}, "c"))
+	assert.False(t, generics.Contains(nil, "a"))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, generics.Equal([]string{"a", "b"}, []string{"a", "b"}))
+	assert.True(t, generics.Equal([]string{}, nil))
+	assert.False(t, generics.Equal([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, generics.Equal([]string{"a"}, []string{"a", "b"}))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// action is something done with a key that can be rebound in the config file.
+type action string
+
+const (
+	actionComplete     action = "complete"
+	actionSearch       action = "search"
+	actionDetails      action = "details"
+	actionStages       action = "stages"
+	actionTable        action = "table"
+	actionSaved        action = "saved"
+	actionRerun        action = "rerun"
+	actionSchema       action = "schema"
+	actionChart        action = "chart"
+	actionCompare      action = "compare"
+	actionDiff         action = "diff"
+	actionNewTab       action = "new_tab"
+	actionOpen         action = "open"
+	actionCloseTab     action = "close_tab"
+	actionNextTab      action = "next_tab"
+	actionPrevTab      action = "previous_tab"
+	actionYank         action = "yank"
+	actionYankPretty   action = "yank_pretty"
+	actionYankField    action = "yank_field"
+	actionPipe         action = "pipe"
+	actionMark         action = "mark"
+	actionDiffMarked   action = "diff_marked"
+	actionWidenColumn  action = "widen_column"
+	actionNarrowColumn action = "narrow_column"
+	actionHideColumn   action = "hide_column"
+	actionShowColumns  action = "show_columns"
+	actionPinColumn    action = "pin_column"
+)
+
+// binding is a key an action can be bound to: either a special key, or a
+// character, in which case key is tcell.KeyRune.
+type binding struct {
+	key  tcell.Key
+	char rune
+}
+
+// char returns the binding of the character.
+func char(r rune) binding {
+	return binding{key: tcell.KeyRune, char: r}
+}
+
+// bindingOf returns the binding of the key pressed.
+func bindingOf(ev *tcell.EventKey) binding {
+	if ev.Key() == tcell.KeyRune {
+		return char(ev.Rune())
+	}
+
+	return binding{key: ev.Key()}
+}
+
+// defaultKeys are the keys of the actions, unless the config file rebinds them.
+var defaultKeys = map[action]binding{
+	actionComplete:     {key: tcell.KeyTab},
+	actionSearch:       {key: tcell.KeyCtrlR},
+	actionDetails:      {key: tcell.KeyEnter},
+	actionStages:       {key: tcell.KeyF2},
+	actionTable:        {key: tcell.KeyF3},
+	actionSaved:        {key: tcell.KeyF4},
+	actionRerun:        {key: tcell.KeyF5},
+	actionSchema:       {key: tcell.KeyF6},
+	actionChart:        {key: tcell.KeyF7},
+	actionCompare:      {key: tcell.KeyF8},
+	actionDiff:         {key: tcell.KeyF9},
+	actionNewTab:       {key: tcell.KeyCtrlT},
+	actionOpen:         {key: tcell.KeyCtrlO},
+	actionCloseTab:     {key: tcell.KeyCtrlX},
+	actionNextTab:      {key: tcell.KeyCtrlN},
+	actionPrevTab:      {key: tcell.KeyCtrlP},
+	actionYank:         char('y'),
+	actionYankPretty:   char('Y'),
+	actionYankField:    char('v'),
+	actionPipe:         char('|'),
+	actionMark:         char('m'),
+	actionDiffMarked:   char('d'),
+	actionWidenColumn:  char('+'),
+	actionNarrowColumn: char('-'),
+	actionHideColumn:   char('h'),
+	actionShowColumns:  char('H'),
+	actionPinColumn:    char('p'),
+}
+
+// resultActions are the actions on the results, which are only taken while
+// the results are in focus. Unlike the others, they can be bound to
+// characters, since those aren't typed into the query while the results are
+// in focus.
+var resultActions = map[action]bool{
+	actionYank:         true,
+	actionYankPretty:   true,
+	actionYankField:    true,
+	actionPipe:         true,
+	actionMark:         true,
+	actionDiffMarked:   true,
+	actionWidenColumn:  true,
+	actionNarrowColumn: true,
+	actionHideColumn:   true,
+	actionShowColumns:  true,
+	actionPinColumn:    true,
+}
+
+// fixedKeys are the keys that can't be rebound, since they also do things
+// outside of the actions, e.g. in the query textbox or in modals, or, for the
+// characters, in the search of the results.
+var fixedKeys = []binding{
+	{key: tcell.KeyEsc},
+	{key: tcell.KeyUp},
+	{key: tcell.KeyDown},
+	{key: tcell.KeyLeft},
+	{key: tcell.KeyRight},
+	char('/'),
+	char('?'),
+	char(':'),
+	char('n'),
+	char('N'),
+}
+
+// keymap maps the actions to their keys, and back.
+type keymap struct {
+	keys    map[action]binding
+	actions map[binding]action
+}
+
+// newKeymap returns the keymap with the given actions rebound to the given keys,
+// which are named as tcell names them, e.g. F6 or Ctrl-T, ignoring case, or are
+// single characters. Only the actions on the results can be bound to
+// characters, since the others are typed into the query. An action whose
+// default key is rebound to another action is left without a key, unless it is
+// rebound too.
+func newKeymap(overrides map[string]string) (*keymap, error) {
+	keysByName := make(map[string]tcell.Key, len(tcell.KeyNames))
+	for key, name := range tcell.KeyNames {
+		keysByName[strings.ToLower(name)] = key
+	}
+
+	k := &keymap{
+		keys:    make(map[action]binding, len(defaultKeys)),
+		actions: make(map[binding]action, len(defaultKeys)),
+	}
+	rebound := map[action]binding{}
+	for name, keyName := range overrides {
+		a := action(name)
+		if _, ok := defaultKeys[a]; !ok {
+			return nil, fmt.Errorf("unknown action %q, expected one of %v", name, actionNames())
+		}
+
+		var b binding
+		if runes := []rune(keyName); len(runes) == 1 {
+			if !resultActions[a] {
+				return nil, fmt.Errorf("%s can't be bound to the character %q, since it would be typed into the query", name, keyName)
+			}
+			b = char(runes[0])
+		} else if key, ok := keysByName[strings.ToLower(keyName)]; ok {
+			b = binding{key: key}
+		} else {
+			return nil, fmt.Errorf("unknown key %q for %s", keyName, name)
+		}
+		for _, fixed := range fixedKeys {
+			if b == fixed {
+				return nil, fmt.Errorf("%s can't be bound to %s", b, name)
+			}
+		}
+		if other, ok := k.actions[b]; ok {
+			return nil, fmt.Errorf("%s is bound to both %s and %s", b, other, a)
+		}
+		rebound[a] = b
+		k.actions[b] = a
+	}
+
+	for a, b := range defaultKeys {
+		if reboundKey, ok := rebound[a]; ok {
+			k.keys[a] = reboundKey
+		} else if _, taken := k.actions[b]; !taken {
+			k.keys[a] = b
+			k.actions[b] = a
+		}
+	}
+
+	return k, nil
+}
+
+// Action returns the action bound to the key pressed, if any.
+func (k *keymap) Action(ev *tcell.EventKey) (action, bool) {
+	a, ok := k.actions[bindingOf(ev)]
+	return a, ok
+}
+
+// Label returns the short name of the key bound to the given action, for the
+// footer, or an empty string if it has none.
+func (k *keymap) Label(a action) string {
+	b, ok := k.keys[a]
+	if !ok {
+		return ""
+	}
+	if b.key == tcell.KeyRune {
+		return string(b.char)
+	}
+
+	name := tcell.KeyNames[b.key]
+	if strings.HasPrefix(name, "Ctrl-") {
+		return "^" + strings.TrimPrefix(name, "Ctrl-")
+	}
+
+	return name
+}
+
+// String returns the name of the key, as it is named in the config file.
+func (b binding) String() string {
+	if b.key == tcell.KeyRune {
+		return strconv.QuoteRune(b.char)
+	}
+
+	return tcell.KeyNames[b.key]
+}
+
+// actionNames returns the names of the actions, sorted.
+func actionNames() []string {
+	names := make([]string, 0, len(defaultKeys))
+	for a := range defaultKeys {
+		names = append(names, string(a))
+	}
+	sort.Strings(names)
+
+	return names
+}
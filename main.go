@@ -2,21 +2,24 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
 	"log"
+	"os"
+	"strings"
+	"unicode"
 
 	"github.com/gcla/gowid"
 	"github.com/gcla/gowid/examples"
 	"github.com/gcla/gowid/widgets/edit"
-	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/fill"
 	"github.com/gcla/gowid/widgets/holder"
 	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/overlay"
 	"github.com/gcla/gowid/widgets/pile"
 	"github.com/gcla/gowid/widgets/styled"
 	"github.com/gcla/gowid/widgets/text"
-	"github.com/gcla/gowid/widgets/vpadding"
+	"github.com/gdamore/tcell"
 	"github.com/utagai/look/config"
 	"github.com/utagai/look/config/custom"
 	"github.com/utagai/look/data"
@@ -32,135 +35,297 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to get a configuration: %v", err)
 	}
-	var src io.Reader = cfg.Source
+	d, err := openData(cfg, cfg.Source)
+	if err != nil {
+		log.Fatalf("failed to open the source: %v", err)
+	}
+
+	if cfg.Batch {
+		if err := runBatch(context.Background(), d, cfg.Query, cfg.Pretty, os.Stdout); err != nil {
+			log.Fatalf("failed to run the query: %v", err)
+		}
+		return
+	}
+
+	theme, err := getTheme(cfg.Theme)
+	if err != nil {
+		log.Fatalf("failed to get the theme: %v", err)
+	}
+	palette := theme.palette()
+	if err := overridePalette(palette, cfg.Palette); err != nil {
+		log.Fatalf("failed to override the palette: %v", err)
+	}
+	keys, err := newKeymap(cfg.Keys)
+	if err != nil {
+		log.Fatalf("failed to bind the keys: %v", err)
+	}
+
+	initializeGowid(d, cfg, palette, keys, newQueryHistory(cfg))
+}
+
+// openData reads the datums of the source into the backend of the config.
+func openData(cfg *config.Config, source *os.File) (data.Data, error) {
+	var src io.Reader = source
 	if cfg.CustomFields != nil {
+		var err error
 		src, err = custom.NewFieldsReader(src, cfg.CustomFields, DefaultBufSizeBytes)
 		if err != nil {
-			log.Fatalf("failed to create a custom fields reader: %v", err)
+			return nil, fmt.Errorf("failed to create a custom fields reader: %w", err)
 		}
 	}
 
 	// FIXME: This is dangerous if the source is large.
-	bytes, err := io.ReadAll(src)
+	datums, err := datum.ReadJSON(src)
 	if err != nil {
-		log.Fatalf("failed to read all the bytes from the source (%q): %v", cfg.Source.Name(), err)
+		return nil, fmt.Errorf("failed to read the source (%q): %w", source.Name(), err)
 	}
 
-	datums := []datum.Datum{}
-	if err := json.Unmarshal(bytes, &datums); err != nil {
-		log.Fatalf("failed to unmarshal data into JSON: %v", err)
-	}
-
-	var d data.Data
 	switch cfg.Backend.Type {
 	case config.BackendTypeMemory:
-		d = data.NewMemoryData(datums, query.NewLiquidQueryExecutor())
+		return data.NewMemoryData(datums, query.NewLiquidQueryExecutor()), nil
 	case config.BackendTypeMongoDB:
-		d, err = data.NewMongoDBData(cfg.Backend.MongoDB, "look", cfg.Source.Name(), datums)
+		d, err := data.NewMongoDBData(cfg.Backend.MongoDB, "look", source.Name(), datums)
 		if err != nil {
-			log.Fatalf("failed to create the MongoDB backend: %v", err)
+			return nil, fmt.Errorf("failed to create the MongoDB backend: %w", err)
 		}
+		return d, nil
 	default:
-		log.Fatalf("unexpected backend type %q", cfg.Backend.Type)
+		return nil, fmt.Errorf("unexpected backend type %q", cfg.Backend.Type)
 	}
-
-	initializeGowid(d)
 }
 
-func initializeGowid(d data.Data) {
-	palette := gowid.Palette{
-		"title": gowid.MakePaletteEntry(gowid.ColorWhite, gowid.ColorBlack),
-		"key":   gowid.MakePaletteEntry(gowid.ColorCyan, gowid.ColorBlack),
-		"foot":  gowid.MakePaletteEntry(gowid.ColorWhite, gowid.ColorBlack),
-		"body":  gowid.MakePaletteEntry(gowid.ColorWhite, gowid.ColorNone),
-		"fbody": gowid.MakePaletteEntry(gowid.ColorWhite, gowid.ColorBlack),
-	}
-
+func initializeGowid(d data.Data, cfg *config.Config, palette gowid.Palette, keys *keymap, history *queryHistory) {
 	key := gowid.MakePaletteRef("key")
 	foot := gowid.MakePaletteRef("foot")
 	title := gowid.MakePaletteRef("title")
-	body := gowid.MakePaletteRef("body")
 
 	footerContent := []text.ContentSegment{
-		text.StyledContent("look | ", title),
-		text.StyledContent("ESC", key),
-		text.StringContent(" exits."),
+		text.StyledContent("look |", title),
+	}
+	for _, binding := range []struct{ key, action string }{
+		{"ESC", "exit"},
+		{keys.Label(actionComplete), "complete"},
+		{"↑↓", "history"},
+		{keys.Label(actionSearch), "search"},
+		{keys.Label(actionDetails), "details"},
+		{"/ :", "find"},
+		{keys.Label(actionYank), "yank"},
+		{keys.Label(actionYankPretty), "yank pretty"},
+		{keys.Label(actionYankField), "yank field"},
+		{keys.Label(actionPipe), "pipe"},
+		{keys.Label(actionMark), "mark"},
+		{keys.Label(actionDiffMarked), "diff marked"},
+		{keys.Label(actionWidenColumn), "widen column"},
+		{keys.Label(actionNarrowColumn), "narrow column"},
+		{keys.Label(actionHideColumn), "hide column"},
+		{keys.Label(actionShowColumns), "show columns"},
+		{keys.Label(actionPinColumn), "pin column"},
+		{keys.Label(actionStages), "stages"},
+		{keys.Label(actionTable), "table"},
+		{keys.Label(actionSaved), "saved"},
+		{keys.Label(actionRerun), "rerun"},
+		{keys.Label(actionSchema), "schema"},
+		{keys.Label(actionChart), "chart"},
+		{keys.Label(actionCompare), "compare"},
+		{keys.Label(actionDiff), "diff"},
+		{keys.Label(actionNewTab), "new tab"},
+		{keys.Label(actionOpen), "open"},
+		{keys.Label(actionCloseTab), "close tab"},
+		{keys.Label(actionNextTab), "next tab"},
+		{keys.Label(actionPrevTab), "previous tab"},
+	} {
+		if binding.key == "" {
+			// The action's key was given to another.
+			continue
+		}
+		footerContent = append(footerContent,
+			text.StringContent(" "),
+			text.StyledContent(binding.key, key),
+			text.StringContent(" "+binding.action),
+		)
 	}
 
 	footerText := styled.New(text.NewFromContent(text.NewContent(footerContent)), foot)
 
-	walker := data.NewDataWalker(d)
-	lb := list.NewBounded(walker)
-	styledLb := styled.New(lb, body)
-
-	queryTextbox := edit.New(edit.Options{Caption: "Query: "})
-
-	framedQueryTextboxValid := framed.New(queryTextbox, framed.Options{
-		Frame: framed.UnicodeFrame,
-		Style: gowid.MakeForeground(gowid.ColorGreen),
-	})
-	framedQueryTextboxInvalid := framed.New(queryTextbox, framed.Options{
-		Frame: framed.UnicodeFrame,
-		Style: gowid.MakeForeground(gowid.ColorRed),
+	// The tabs are only created once the app is, since they run their queries
+	// on it.
+	root := holder.New(text.New(""))
+	app, err := gowid.NewApp(gowid.AppArgs{
+		View:    root,
+		Palette: &palette,
 	})
+	examples.ExitOnErr(err)
 
-	queryStatusTextbox := edit.New(edit.Options{Text: "Done."})
-
-	framedQueryStatusTextboxValid := framed.New(queryStatusTextbox, framed.Options{
-		Frame: framed.UnicodeFrame,
-		Style: gowid.MakeForeground(gowid.ColorGreen),
-	})
-	framedQueryStatusTextboxInvalid := framed.New(queryStatusTextbox, framed.Options{
-		Frame: framed.UnicodeFrame,
-		Style: gowid.MakeForeground(gowid.ColorRed),
-	})
-	queryStatusTextboxHolder := holder.New(framedQueryStatusTextboxValid)
-
-	queryTextboxHolder := holder.New(framedQueryTextboxValid)
-	queryTextbox.OnTextSet(gowid.WidgetCallback{
-		Name: "on query text change",
-		WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
-			newData, err := d.Find(context.Background(), queryTextbox.Text())
-			if errors.Is(err, query.ErrUnableToParseQuery) {
-				log.Printf("incomplete query: %q", queryTextbox.Text())
-				queryTextboxHolder.SetSubWidget(framedQueryTextboxInvalid, app)
-				queryStatusTextboxHolder.SetSubWidget(framedQueryStatusTextboxInvalid, app)
-				queryStatusTextbox.SetText(err.Error(), app)
-				return
-			} else if err != nil {
-				log.Fatalf("failed to construct the new data: %v", err)
-			}
-			queryTextboxHolder.SetSubWidget(framedQueryTextboxValid, app)
-			queryStatusTextboxHolder.SetSubWidget(framedQueryStatusTextboxValid, app)
-			queryStatusTextbox.SetText("Done.", app)
-			lb.SetWalker(data.NewDataWalker(newData), app)
+	bar := newTabBar()
+	var tabs *tabSet
+	env := &tabEnv{
+		app:     app,
+		cfg:     cfg,
+		keys:    keys,
+		history: history,
+		root:    root,
+		loop:    newMainLoop(app),
+		onChange: func(app gowid.IApp) {
+			tabs.Refresh(app)
 		},
-	})
+	}
+	tabs = newTabSet(env, bar)
+	tabs.Add(app, newTab(env, newSource(cfg.Source.Name(), d), cfg.Query))
 
 	view := pile.New([]gowid.IContainerWidget{
 		&gowid.ContainerWidget{
-			IWidget: vpadding.New(queryTextboxHolder, gowid.VAlignMiddle{}, gowid.RenderFlow{}),
+			IWidget: bar,
 			D:       gowid.RenderFlow{},
 		},
 		&gowid.ContainerWidget{
-			IWidget: styledLb,
+			IWidget: tabs,
 			D:       gowid.RenderWithWeight{W: 1},
 		},
-		&gowid.ContainerWidget{
-			IWidget: vpadding.New(queryStatusTextboxHolder, gowid.VAlignMiddle{}, gowid.RenderFlow{}),
-			D:       gowid.RenderFlow{},
-		},
 		&gowid.ContainerWidget{
 			IWidget: footerText,
 			D:       gowid.RenderFlow{},
 		},
 	})
+	// The root holds the view, and any modal shown over it. The global keys take
+	// priority over the widget in focus, since e.g. the query textbox would
+	// otherwise take Enter as a newline.
+	root.SetSubWidget(&keyHandler{
+		IWidget: view,
+		handle: func(app gowid.IApp, ev *tcell.EventKey) bool {
+			return tabs.HandleKey(app, ev) || tabs.Current().HandleKey(app, ev)
+		},
+	}, app)
 
-	app, err := gowid.NewApp(gowid.AppArgs{
-		View:    view,
-		Palette: &palette,
+	loopErr := env.loop.Run()
+
+	tabs.CloseAll()
+	if loopErr != nil {
+		log.Fatalf("look quit: %v", loopErr)
+	}
+}
+
+// keyHandler gives its handler the first chance to handle the keys sent to the
+// widget it wraps.
+type keyHandler struct {
+	gowid.IWidget
+	handle func(app gowid.IApp, ev *tcell.EventKey) bool
+}
+
+// UserInput implements the gowid.IWidget interface.
+func (k *keyHandler) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	if evk, ok := ev.(*tcell.EventKey); ok && k.handle(app, evk) {
+		return true
+	}
+
+	return k.IWidget.UserInput(ev, size, focus, app)
+}
+
+// showDetail shows the datum in focus in the list in a modal over the view.
+func showDetail(app gowid.IApp, walker *data.DataWalker, root *holder.Widget) {
+	index := int(walker.Focus().(list.ListPos))
+	d, err := walker.Data().At(context.Background(), index)
+	if err != nil {
+		// Either there is nothing in focus, or the walker has already reported
+		// the error.
+		return
+	}
+
+	var closeModal func(gowid.IApp)
+	detail := newDetailView(fmt.Sprintf("datum #%d", index), d, func(app gowid.IApp) {
+		closeModal(app)
 	})
-	examples.ExitOnErr(err)
+	closeModal = showModal(app, root, detail)
+}
+
+// pipe pipes the results to the command, with the UI suspended while it runs.
+func pipe(app gowid.IApp, loop *mainLoop, results data.Data, command string, pretty bool, status *statusBar) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+
+	var pipeErr error
+	suspendErr := loop.Suspend(func() {
+		pipeErr = pipeResults(results, command, pretty)
+	})
+	switch {
+	case pipeErr != nil:
+		status.SetHint(app, fmt.Sprintf("Failed to pipe the results: %v", pipeErr))
+	case suspendErr != nil:
+		// The terminal is back if the app is still running, so the failure
+		// can be shown.
+		status.SetHint(app, fmt.Sprintf("Piped the results, but %v", suspendErr))
+	}
+}
+
+// showDiff shows the changes from the marked datum to the result in focus in a
+// modal over the view.
+func showDiff(app gowid.IApp, marked datum.Datum, markedIndex int, results *resultsView, root *holder.Widget) {
+	index, d, err := results.Focused()
+	if err != nil {
+		// Either there is nothing in focus, or the walker has already reported
+		// the error.
+		return
+	}
 
-	app.SimpleMainLoop()
+	var closeModal func(gowid.IApp)
+	title := fmt.Sprintf("marked result #%d → result #%d", markedIndex, index)
+	view := newDiffView(title, marked, d, func(app gowid.IApp) {
+		closeModal(app)
+	})
+	closeModal = showModal(app, root, view)
+}
+
+// showSaved shows the saved queries in a modal over the view, and puts the one
+// picked in the query textbox, which runs it.
+func showSaved(app gowid.IApp, queries map[string]string, textbox *edit.Widget, root *holder.Widget) {
+	var closeModal func(gowid.IApp)
+	saved := newSavedView(queries, func(app gowid.IApp, query string) {
+		setQuery(app, textbox, query)
+	}, func(app gowid.IApp) {
+		closeModal(app)
+	})
+	closeModal = showModal(app, root, saved)
+}
+
+// insertIntoQuery inserts the text into the query at the cursor, separated from
+// whatever is before it by a space.
+func insertIntoQuery(app gowid.IApp, textbox *edit.Widget, s string) {
+	query := []rune(textbox.Text())
+	pos := textbox.CursorPos()
+	if pos > 0 && !unicode.IsSpace(query[pos-1]) && query[pos-1] != '(' {
+		s = " " + s
+	}
+
+	textbox.SetText(string(query[:pos])+s+string(query[pos:]), app)
+	textbox.SetCursorPos(pos+len([]rune(s)), app)
+}
+
+// setQuery puts the query in the textbox, with the cursor at its end.
+func setQuery(app gowid.IApp, textbox *edit.Widget, query string) {
+	textbox.SetText(query, app)
+	textbox.SetCursorPos(len([]rune(query)), app)
+}
+
+// showModal shows the widget in a modal over the view held by the root, and
+// returns a function that closes it again.
+func showModal(app gowid.IApp, root *holder.Widget, modal gowid.IWidget) func(gowid.IApp) {
+	view := root.SubWidget()
+	// The cells the modal leaves empty would otherwise show the view beneath it.
+	opaque := overlay.New(
+		modal, fill.New(' '),
+		gowid.VAlignTop{}, gowid.RenderWithRatio{R: 1},
+		gowid.HAlignLeft{}, gowid.RenderWithRatio{R: 1},
+	)
+	root.SetSubWidget(
+		overlay.New(
+			styled.New(opaque, gowid.MakePaletteRef("modal")), view,
+			gowid.VAlignMiddle{}, gowid.RenderWithRatio{R: 0.8},
+			gowid.HAlignMiddle{}, gowid.RenderWithRatio{R: 0.8},
+		),
+		app,
+	)
+
+	return func(app gowid.IApp) {
+		root.SetSubWidget(view, app)
+	}
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/gcla/gowid"
+	"github.com/utagai/look/data"
+)
+
+// mainLoop runs the app as gowid's own main loop does, but keeps hold of the
+// runner of the terminal's events, so that the UI can be suspended while
+// another program has the terminal.
+type mainLoop struct {
+	app    *gowid.App
+	runner *gowid.AppRunner
+	// err is why the terminal couldn't be taken back, if it couldn't, in
+	// which case the loop quits.
+	err error
+}
+
+func newMainLoop(app *gowid.App) *mainLoop {
+	return &mainLoop{app: app}
+}
+
+// Run runs the app until it quits. Like gowid's simple main loop, it quits on
+// the quit keys that aren't otherwise handled. It returns an error if it quit
+// because the terminal couldn't be taken back after suspending the UI.
+func (l *mainLoop) Run() error {
+	defer func() {
+		// There is no screen to close if it couldn't be taken back.
+		if l.err == nil {
+			l.app.Close()
+		}
+	}()
+	l.runner = l.app.Runner()
+	l.runner.Start()
+	// The runner is replaced whenever the UI is suspended.
+	defer func() { l.runner.Stop() }()
+
+	unhandled := gowid.UnhandledInputFunc(gowid.HandleQuitKeys)
+	for {
+		select {
+		case ev := <-l.app.TCellEvents:
+			l.app.HandleTCellEvent(ev, unhandled)
+		case ev := <-l.app.AfterRenderEvents:
+			if ev == nil {
+				// The app quit.
+				return l.err
+			}
+			l.app.RunThenRenderEvent(ev)
+		}
+	}
+}
+
+// Suspend gives the terminal back for as long as f runs, and takes it again
+// once f returns. It must be called from the app's goroutine, e.g. when
+// handling a key. If the terminal can't be taken back, the user is asked to
+// fix it and press Enter to try again, and the first failure is returned once
+// the terminal is back. If the terminal can't be asked either, the app quits.
+func (l *mainLoop) Suspend(f func()) error {
+	l.runner.Stop()
+	l.app.DeactivateScreen()
+
+	f()
+
+	var firstErr error
+	for {
+		err := l.app.ActivateScreen()
+		if err == nil {
+			break
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to take back the terminal: %w", err)
+		}
+		if askErr := askToRetry(err); askErr != nil {
+			l.err = fmt.Errorf("failed to take back the terminal: %v, and to ask to try again: %w", err, askErr)
+			l.app.Quit()
+			return l.err
+		}
+	}
+	l.runner = l.app.Runner()
+	l.runner.Start()
+	return firstErr
+}
+
+// askToRetry tells the user on the terminal that it couldn't be taken back,
+// and waits for them to press Enter to try again.
+func askToRetry(err error) error {
+	tty, ttyErr := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if ttyErr != nil {
+		return ttyErr
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "\nFailed to take back the terminal: %v\nPress Enter to try again.", err)
+	_, readErr := bufio.NewReader(tty).ReadString('\n')
+	return readErr
+}
+
+// pipeResults runs the command with the shell, with the results on its
+// standard input, as JSON, one datum per line, or indented if pretty is set.
+// The command has the terminal, and once it exits, the user is asked to press
+// Enter, so that they get to read what it printed.
+func pipeResults(results data.Data, command string, pretty bool) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open the terminal: %w", err)
+	}
+	defer tty.Close()
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to connect to %q: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %q: %w", command, err)
+	}
+
+	writeErr := writeResults(context.Background(), results, pretty, stdin)
+	stdin.Close()
+	runErr := cmd.Wait()
+
+	fmt.Fprint(tty, "\nPress Enter to return to look.")
+	_, _ = bufio.NewReader(tty).ReadString('\n')
+
+	if runErr != nil {
+		return fmt.Errorf("%q failed: %w", command, runErr)
+	}
+	// Commands like head are free to stop reading before the end.
+	if writeErr != nil && !errors.Is(writeErr, syscall.EPIPE) {
+		return fmt.Errorf("failed to write to %q: %w", command, writeErr)
+	}
+
+	return nil
+}
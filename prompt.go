@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/gcla/gowid"
+	"github.com/gdamore/tcell"
+)
+
+// prompt is a line typed in the status bar, like the prompts of a pager, e.g.
+// /pattern or |command. It starts with the key that opened it.
+type prompt struct {
+	// setHint shows the prompt in the status bar.
+	setHint func(app gowid.IApp, hint string)
+
+	text     string
+	active   bool
+	onSubmit func(app gowid.IApp, text string)
+}
+
+func newPrompt(setHint func(gowid.IApp, string)) *prompt {
+	return &prompt{setHint: setHint}
+}
+
+// Start opens the prompt with the given text, and calls onSubmit with what
+// follows it once Enter is pressed.
+func (p *prompt) Start(app gowid.IApp, text string, onSubmit func(gowid.IApp, string)) {
+	p.active = true
+	p.text = text
+	p.onSubmit = onSubmit
+	p.setHint(app, p.hint())
+}
+
+// Active returns whether the prompt is open, in which case keys should go to
+// HandleKey.
+func (p *prompt) Active() bool {
+	return p.active
+}
+
+// HandleKey handles a key while the prompt is open. Enter submits the prompt,
+// and Esc, or Backspace past its start, closes it. Every key is handled, so that
+// the prompt doesn't lose keys to the widgets beneath it.
+func (p *prompt) HandleKey(app gowid.IApp, ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		p.text += string(ev.Rune())
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		runes := []rune(p.text)
+		if len(runes) == 1 {
+			p.close(app)
+			return
+		}
+		p.text = string(runes[:len(runes)-1])
+	case tcell.KeyEsc, tcell.KeyCtrlG:
+		p.close(app)
+		return
+	case tcell.KeyEnter:
+		p.close(app)
+		p.onSubmit(app, string([]rune(p.text)[1:]))
+		return
+	}
+
+	p.setHint(app, p.hint())
+}
+
+func (p *prompt) close(app gowid.IApp) {
+	p.active = false
+	p.setHint(app, "")
+}
+
+func (p *prompt) hint() string {
+	return p.text + "▏ Enter to go, ESC cancels"
+}
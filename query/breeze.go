@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/utagai/look/datum"
@@ -10,11 +11,70 @@ import (
 
 type LiquidQueryExecutor struct{}
 
+var _ ProfilingExecutor = (*LiquidQueryExecutor)(nil)
+var _ MatchingExecutor = (*LiquidQueryExecutor)(nil)
+
 func NewLiquidQueryExecutor() *LiquidQueryExecutor {
 	return &LiquidQueryExecutor{}
 }
 
-func (s *LiquidQueryExecutor) Find(q string, datums []datum.Datum) ([]datum.Datum, error) {
+func (s *LiquidQueryExecutor) Find(ctx context.Context, q string, datums []datum.Datum) ([]datum.Datum, error) {
+	datums, _, err := s.FindProfiled(ctx, q, datums)
+	return datums, err
+}
+
+func (s *LiquidQueryExecutor) FindProfiled(
+	ctx context.Context,
+	q string,
+	datums []datum.Datum,
+) ([]datum.Datum, []StageStats, error) {
+	stages, err := parse(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream, profile, err := execution.ExecuteProfiled(ctx, datum.NewSliceStream(datums), stages)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute: %w", err)
+	}
+
+	results, err := datum.StreamToSlice(ctx, stream)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return results, stageStats(profile.Stages()), nil
+}
+
+// stageStats converts the stats of an execution's stages.
+func stageStats(executed []execution.StageStats) []StageStats {
+	stats := make([]StageStats, len(executed))
+	for i, stage := range executed {
+		stats[i] = StageStats{
+			Stage:   stage.Stage,
+			RowsIn:  stage.RowsIn,
+			RowsOut: stage.RowsOut,
+			Elapsed: stage.Elapsed,
+		}
+	}
+
+	return stats
+}
+
+// Matcher implements the MatchingExecutor interface. The parts of a datum that
+// matched are those that pass the query's filters, see execution.MatchSpans.
+func (s *LiquidQueryExecutor) Matcher(q string) (func(datum.Datum) []datum.Span, error) {
+	stages, err := parse(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(d datum.Datum) []datum.Span {
+		return execution.MatchSpans(stages, d)
+	}, nil
+}
+
+func parse(q string) ([]breeze.Stage, error) {
 	p := breeze.NewParser(q)
 	stages, err := p.Parse()
 	if err != nil {
@@ -22,11 +82,5 @@ func (s *LiquidQueryExecutor) Find(q string, datums []datum.Datum) ([]datum.Datu
 		return nil, fmt.Errorf("%w:\n%v", ErrUnableToParseQuery, parseErr.ErrorDescription())
 	}
 
-	var stream datum.Stream = datum.NewSliceStream(datums)
-	stream, err = execution.Execute(stream, stages)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute: %w", err)
-	}
-
-	return datum.StreamToSlice(stream)
+	return stages, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // BinaryOp enumerates the kinds of binary operations in breeze.
@@ -305,13 +306,45 @@ func (s *Sort) Name() string {
 	return "sort"
 }
 
+// Top is a stage that keeps only the K highest (or lowest) datums by a field,
+// optionally for each distinct value of another field. Unlike a Sort, it only
+// needs to keep K datums (per group) in memory.
+type Top struct {
+	K          int
+	Field      string
+	Descending bool
+	PerField   *string
+}
+
+// Name implements the Stage interface.
+func (t *Top) Name() string {
+	return "top"
+}
+
+// Sample is a stage that randomly samples the data, either down to a fixed
+// number of datums, or by keeping each datum with a given probability.
+type Sample struct {
+	// Exactly one of Size or Percent is set.
+	Size    int
+	Percent float64
+	// Seed seeds the random sampling for reproducibility. If nil, a different
+	// sample is drawn every time.
+	Seed *int64
+}
+
+// Name implements the Stage interface.
+func (s *Sample) Name() string {
+	return "sample"
+}
+
 // Stage is a single unit of processing to apply to the data.
 type Stage interface {
 	Name() string
 }
 
 // Group is a stage that performs grouping of data and aggregates computations
-// over them.
+// over them. Each group reports its value of the group-by field alongside the
+// aggregate, and the groups are ordered by those values.
 type Group struct {
 	AggFunc        AggregateFunc
 	GroupByField   *string
@@ -323,6 +356,23 @@ func (g *Group) Name() string {
 	return "group"
 }
 
+// AggregateAs returns the name of the aggregate in the output. It is the
+// aggregated field, unless that would overlap the group-by field, in which
+// case it is named for the aggregate function too, e.g. count_g, so that the
+// value of the group isn't lost.
+func (g *Group) AggregateAs() string {
+	if g.GroupByField == nil {
+		return g.AggregateField
+	}
+
+	key, aggregate := *g.GroupByField, g.AggregateField
+	if key == aggregate || strings.HasPrefix(key, aggregate+".") || strings.HasPrefix(aggregate, key+".") {
+		return fmt.Sprintf("%s_%s", g.AggFunc, aggregate)
+	}
+
+	return aggregate
+}
+
 // AggregateFunc is an aggregate function.
 type AggregateFunc string
 
@@ -337,6 +387,77 @@ const (
 	AggFuncStdDev AggregateFunc = "stddev"
 )
 
+// Window is a stage that computes a function over a window of datums for each
+// datum, e.g. running sums or rolling averages, and assigns the result to a
+// new field.
+type Window struct {
+	Func WindowFunc
+	// Field is the field the function is computed over. It is empty for
+	// functions that do not take a field, like row_number.
+	Field          string
+	PartitionField *string
+	// Frame restricts the window to a subset of the preceding datums. If it is
+	// nil, the window includes every preceding datum of the partition (a running
+	// aggregate).
+	Frame *WindowFrame
+	// Offset is how far back (lag) or forward (lead) to look.
+	Offset int
+	As     string
+}
+
+// Name implements the Stage interface.
+func (w *Window) Name() string {
+	return "window"
+}
+
+// WindowFrame describes the datums preceding (and including) a datum that are
+// part of its window. Exactly one of Rows or Range is set.
+type WindowFrame struct {
+	// Rows is the number of datums in the frame.
+	Rows int
+	// Range is the time span covered by the frame, measured on RangeField.
+	Range      time.Duration
+	RangeField string
+}
+
+// WindowFunc is a function computed over a window. Every AggregateFunc is also
+// a valid WindowFunc.
+type WindowFunc string
+
+// The window-only functions in breeze.
+const (
+	WindowFuncRowNumber WindowFunc = "row_number"
+	WindowFuncLag       WindowFunc = "lag"
+	WindowFuncLead      WindowFunc = "lead"
+)
+
+// Bucket is a stage that groups datums into equal-width ranges (buckets) of a
+// numeric or time field, e.g. for building histograms. Each bucket reports its
+// boundaries, the number of datums in it and, optionally, aggregates.
+type Bucket struct {
+	Field string
+	// Exactly one of Width, Duration or NumBuckets is set. Width and Duration
+	// set the width of each bucket directly, for numbers and times
+	// respectively, while NumBuckets splits the observed range of values into
+	// that many buckets.
+	Width      float64
+	Duration   time.Duration
+	NumBuckets int
+	Aggregates []BucketAggregate
+}
+
+// Name implements the Stage interface.
+func (b *Bucket) Name() string {
+	return "bucket"
+}
+
+// BucketAggregate is an aggregate computed over the datums of each bucket.
+type BucketAggregate struct {
+	AggFunc AggregateFunc
+	Field   string
+	As      string
+}
+
 // Map is a stage that performs transformations on a per-field basis.
 type Map struct {
 	Assignments []FieldAssignment
@@ -348,7 +469,73 @@ func (m *Map) Name() string {
 }
 
 // FieldAssignment is a remapping of a field, found in maps.
+// The field may be a path into nested objects, e.g. a.b.
 type FieldAssignment struct {
 	Field      string
 	Assignment Expr
 }
+
+// Rename is a stage that moves the values of fields to new fields.
+type Rename struct {
+	Renames []FieldRename
+}
+
+// Name implements the Stage interface.
+func (r *Rename) Name() string {
+	return "rename"
+}
+
+// FieldRename is a renaming of a field, found in renames. Either field may be a
+// path into nested objects.
+type FieldRename struct {
+	From string
+	To   string
+}
+
+// Unset is a stage that removes fields.
+type Unset struct {
+	Fields []string
+}
+
+// Name implements the Stage interface.
+func (u *Unset) Name() string {
+	return "unset"
+}
+
+// Select is a stage that keeps only the given fields, dropping the rest.
+type Select struct {
+	Fields []string
+}
+
+// Name implements the Stage interface.
+func (s *Select) Name() string {
+	return "select"
+}
+
+// Lookup is a stage that joins each datum against the datums of a secondary
+// source, matching on a local and a foreign field.
+type Lookup struct {
+	Mode         LookupMode
+	Source       string
+	LocalField   string
+	ForeignField string
+	As           string
+}
+
+// Name implements the Stage interface.
+func (l *Lookup) Name() string {
+	return "lookup"
+}
+
+// LookupMode is the kind of join a Lookup performs.
+type LookupMode string
+
+// The various kinds of lookup modes in breeze.
+const (
+	// LookupModeInner only keeps datums that have at least one match.
+	LookupModeInner LookupMode = "inner"
+	// LookupModeLeft keeps every datum, even the ones without a match.
+	LookupModeLeft LookupMode = "left"
+	// LookupModeAnti only keeps datums that have no matches.
+	LookupModeAnti LookupMode = "anti"
+)
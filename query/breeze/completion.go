@@ -0,0 +1,172 @@
+package breeze
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/utagai/look/internal/generics"
+)
+
+// Expectation is what the parser expects at the end of a partial query, e.g.
+// for completing it.
+type Expectation struct {
+	// Stages is whether a stage may come next.
+	Stages bool
+	// Fields is whether a field may come next.
+	Fields bool
+	// Values is whether a value may come next, i.e. a constant, a field
+	// reference or a function.
+	Values bool
+	// Operators is whether a binary operator may come next.
+	Operators bool
+	// AggFuncs and WindowFuncs are whether an aggregate or window function may
+	// come next.
+	AggFuncs    bool
+	WindowFuncs bool
+	// Keywords are the keywords that may come next, e.g. "by" or "asc".
+	Keywords []string
+	// Separator is whether a stage separator may come next, i.e. whether the
+	// stage so far is complete.
+	Separator bool
+}
+
+func (e *Expectation) merge(other Expectation) {
+	e.Stages = e.Stages || other.Stages
+	e.Fields = e.Fields || other.Fields
+	e.Values = e.Values || other.Values
+	e.Operators = e.Operators || other.Operators
+	e.AggFuncs = e.AggFuncs || other.AggFuncs
+	e.WindowFuncs = e.WindowFuncs || other.WindowFuncs
+	e.Separator = e.Separator || other.Separator
+	for _, keyword := range other.Keywords {
+		if !generics.Contains(e.Keywords, keyword) {
+			e.Keywords = append(e.Keywords, keyword)
+		}
+	}
+}
+
+// Expect returns what may come next at the end of the given partial query.
+func Expect(query string) Expectation {
+	p := NewParser(query)
+	p.expected = &Expectation{}
+	// The query is expected to be incomplete, so the error is moot. All that
+	// matters is what the parser was looking for when it reached the end.
+	_, _ = p.parse()
+
+	return *p.expected
+}
+
+// expect records that the parser expects e next, if the next token is the end
+// of the query.
+func (p *Parser) expect(e Expectation) {
+	if p.expected == nil {
+		return
+	}
+
+	if token, _ := p.tokenizer.Peek(); token == TokenEOF {
+		p.expected.merge(e)
+	}
+}
+
+// expectAt is like expect, but for a token that has already been consumed.
+func (p *Parser) expectAt(token Token, e Expectation) {
+	if p.expected != nil && token == TokenEOF {
+		p.expected.merge(e)
+	}
+}
+
+// Candidates returns the words that may come next, given the fields that exist.
+// Fields are given without their leading '.'. They are offered as field
+// references (e.g. .a.b), since every position that takes a field also takes
+// those.
+func (e Expectation) Candidates(fields []string) []string {
+	candidates := append([]string{}, e.Keywords...)
+	if e.Stages {
+		candidates = append(candidates, stageNames()...)
+	}
+	if e.AggFuncs || e.WindowFuncs {
+		candidates = append(candidates, aggFuncNames...)
+	}
+	if e.WindowFuncs {
+		candidates = append(candidates, string(WindowFuncRowNumber), string(WindowFuncLag), string(WindowFuncLead))
+	}
+	if e.Operators {
+		candidates = append(candidates, "contains", "=", ">", "+", "-", "*", "/")
+	}
+	if e.Fields || e.Values {
+		for _, field := range fields {
+			candidates = append(candidates, "."+field)
+		}
+	}
+	if e.Values {
+		for _, name := range functionNames() {
+			candidates = append(candidates, name+"(")
+		}
+		candidates = append(candidates, "true", "false", "null")
+	}
+	if e.Separator {
+		candidates = append(candidates, StageSeparatorString)
+	}
+
+	return candidates
+}
+
+// Complete returns the word at the end of the given partial query, and the
+// candidates for completing it, given the fields that exist. The word is empty
+// if the query ends between words.
+func Complete(query string, fields []string) (string, []string) {
+	start := len(query)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(query[:start])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' && r != '!' {
+			break
+		}
+		start -= size
+	}
+	word := query[start:]
+
+	candidates := []string{}
+	for _, candidate := range Expect(query[:start]).Candidates(fields) {
+		if strings.HasPrefix(candidate, word) && candidate != word {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return word, candidates
+}
+
+// stageNames returns the names of the stages, sorted.
+func stageNames() []string {
+	names := []string{}
+	for text, token := range identTokens {
+		if token.IsStage() {
+			names = append(names, text)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+var aggFuncNames = []string{
+	string(AggFuncSum),
+	string(AggFuncAvg),
+	string(AggFuncCount),
+	string(AggFuncMin),
+	string(AggFuncMax),
+	string(AggFuncMode),
+	string(AggFuncStdDev),
+}
+
+// functionNames returns the names of the functions, sorted.
+func functionNames() []string {
+	names := make([]string, 0, len(functionValidators))
+	for name := range functionValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
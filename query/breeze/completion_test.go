@@ -0,0 +1,72 @@
+package breeze_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/utagai/look/query/breeze"
+)
+
+func TestExpect(t *testing.T) {
+	tcs := []struct {
+		query    string
+		expected breeze.Expectation
+	}{
+		{query: "", expected: breeze.Expectation{Stages: true}},
+		{query: "filter .a = 1 | ", expected: breeze.Expectation{Stages: true}},
+		{query: "filter ", expected: breeze.Expectation{Values: true, Separator: true}},
+		{query: "filter .a ", expected: breeze.Expectation{Values: true, Operators: true, Separator: true}},
+		{query: "filter .a = ", expected: breeze.Expectation{Values: true}},
+		{query: "filter regex(", expected: breeze.Expectation{Values: true}},
+		{query: "sort ", expected: breeze.Expectation{Fields: true}},
+		{query: "sort .a ", expected: breeze.Expectation{Keywords: []string{"asc", "desc"}, Separator: true}},
+		{query: "group ", expected: breeze.Expectation{AggFuncs: true, Keywords: []string{"by"}}},
+		{query: "group by ", expected: breeze.Expectation{Fields: true}},
+		{query: "group by .a ", expected: breeze.Expectation{AggFuncs: true}},
+		{query: "group by .a sum ", expected: breeze.Expectation{Fields: true}},
+		{query: "window by .a ", expected: breeze.Expectation{WindowFuncs: true}},
+		{query: "bucket .a ", expected: breeze.Expectation{Keywords: []string{"by", "into"}}},
+		{query: "lookup ", expected: breeze.Expectation{Keywords: []string{"inner", "left", "anti"}}},
+		{query: `lookup "x.json" `, expected: breeze.Expectation{Keywords: []string{"on"}}},
+		{query: "top 3 by .a ", expected: breeze.Expectation{Keywords: []string{"asc", "desc", "per"}, Separator: true}},
+		{query: "rename .a ", expected: breeze.Expectation{Keywords: []string{"as"}}},
+		{query: "select .a, ", expected: breeze.Expectation{Fields: true, Separator: true}},
+		{query: "map ", expected: breeze.Expectation{Fields: true, Separator: true}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.expected, breeze.Expect(tc.query))
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	fields := []string{"user.id", "user.name", "title", "naïve"}
+	tcs := []struct {
+		query      string
+		word       string
+		candidates []string
+	}{
+		{query: "fi", word: "fi", candidates: []string{"filter"}},
+		{query: "s", word: "s", candidates: []string{"sample", "select", "sort"}},
+		{query: "sort .u", word: ".u", candidates: []string{".user.id", ".user.name"}},
+		{query: "group by .title c", word: "c", candidates: []string{"count"}},
+		{query: "filter re", word: "re", candidates: []string{"regex("}},
+		{query: "filter .title con", word: "con", candidates: []string{"contains"}},
+		{query: "sort .title d", word: "d", candidates: []string{"desc"}},
+		{query: "sort .title ", word: "", candidates: []string{"asc", "desc", "|"}},
+		{query: "filter .title = 1 | sort .naï", word: ".naï", candidates: []string{".naïve"}},
+		// A complete word has nothing left to complete.
+		{query: "sort", word: "sort", candidates: []string{}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.query, func(t *testing.T) {
+			word, candidates := breeze.Complete(tc.query, fields)
+			assert.Equal(t, tc.word, word)
+			assert.Equal(t, tc.candidates, candidates)
+		})
+	}
+}
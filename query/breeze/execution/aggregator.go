@@ -1,7 +1,10 @@
 package execution
 
 import (
+	"fmt"
 	"math"
+
+	"github.com/utagai/look/query/breeze"
 )
 
 type aggregator interface {
@@ -9,6 +12,27 @@ type aggregator interface {
 	aggregate() interface{}
 }
 
+func newAggregator(aggFunc breeze.AggregateFunc) aggregator {
+	switch aggFunc {
+	case breeze.AggFuncSum:
+		return &sum{}
+	case breeze.AggFuncAvg:
+		return &avg{}
+	case breeze.AggFuncCount:
+		return &count{}
+	case breeze.AggFuncMin:
+		return &min{}
+	case breeze.AggFuncMax:
+		return &max{}
+	case breeze.AggFuncMode:
+		return &mode{}
+	case breeze.AggFuncStdDev:
+		return &stddev{}
+	default:
+		panic(fmt.Sprintf("unrecognized aggregate function: %q", aggFunc))
+	}
+}
+
 type sum struct {
 	numberTotal float64
 	numNumbers  int
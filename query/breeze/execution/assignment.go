@@ -13,6 +13,8 @@ func executeAssignment(assignment breeze.FieldAssignment, datum datum.Datum) err
 	if err != nil {
 		return fmt.Errorf("failed to evaluate assignment: %w", err)
 	}
-	datum[fieldToAssign] = newValue
+	// If the path runs into a non-object, the assignment is skipped for this
+	// datum rather than clobbering the existing value.
+	setField(datum, fieldToAssign, newValue)
 	return nil
 }
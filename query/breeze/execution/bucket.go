@@ -0,0 +1,384 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+// maxFilledBuckets caps the number of buckets the bucket stage will emit,
+// including the empty buckets between the lowest and highest non-empty ones.
+// Past this, the stage fails rather than e.g. producing tens of millions of
+// datums for 1s buckets over a year of data.
+const maxFilledBuckets = 10000
+
+// maxBucketIndex is the furthest from the origin, in buckets, that a number is
+// placed. Past it, float64 can't tell neighbouring buckets apart.
+const maxBucketIndex = 1 << 53
+
+// ErrTooManyBuckets is returned when the values span more buckets than the
+// bucket stage will emit. A wider width or fewer buckets fixes it.
+var ErrTooManyBuckets = errors.New("too many buckets")
+
+func executeBucket(bucket *breeze.Bucket, stream datum.Stream) *BucketStream {
+	return &BucketStream{
+		Bucket: bucket,
+		source: stream,
+	}
+}
+
+// BucketStream is an implementation of datum.Stream for the bucket stage.
+type BucketStream struct {
+	*breeze.Bucket
+	source         datum.Stream
+	bucketedSource datum.Stream
+}
+
+type bucketState struct {
+	count      uint
+	aggregates []aggregator
+}
+
+// bucketing is where each datum with a value for the bucketed field belongs.
+type bucketing struct {
+	datums []datum.Datum
+	// indices are the indices of the buckets each of the datums is in.
+	indices []int64
+	// spread is whether the values span a range, rather than all being
+	// identical.
+	spread bool
+	// bounds returns the lower and upper bounds of the bucket at the index.
+	bounds func(index int64) (interface{}, interface{})
+}
+
+// Next implements the datum.DatumStream interface.
+func (bs *BucketStream) Next(ctx context.Context) (datum.Datum, error) {
+	if bs.bucketedSource == nil {
+		if err := bs.bucketSource(ctx); err != nil {
+			return nil, fmt.Errorf("failed to bucket data: %w", err)
+		}
+	}
+
+	return bs.bucketedSource.Next(ctx)
+}
+
+func (bs *BucketStream) bucketSource(ctx context.Context) error {
+	if bs.NumBuckets > maxFilledBuckets {
+		return fmt.Errorf("%w: can't split into more than %d buckets", ErrTooManyBuckets, maxFilledBuckets)
+	}
+
+	datums, err := datum.StreamToSlice(ctx, bs.source)
+	if err != nil {
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+
+	var b *bucketing
+	if bs.isTimeBucketing(datums) {
+		b, err = bs.bucketTimes(datums)
+	} else {
+		b, err = bs.bucketNumbers(datums)
+	}
+	if err != nil {
+		return err
+	}
+
+	buckets := map[int64]*bucketState{}
+	for i, index := range b.indices {
+		state, ok := buckets[index]
+		if !ok {
+			state = bs.newBucketState()
+			buckets[index] = state
+		}
+
+		state.count++
+		for j, agg := range bs.Aggregates {
+			if fieldValue, ok := getField(b.datums[i], agg.Field); ok {
+				state.aggregates[j].ingest(fieldValue)
+			}
+		}
+	}
+
+	indices, err := bs.indicesToEmit(buckets, b.spread)
+	if err != nil {
+		return err
+	}
+
+	results := []datum.Datum{}
+	for _, index := range indices {
+		state, ok := buckets[index]
+		if !ok {
+			state = bs.newBucketState()
+		}
+
+		lower, upper := b.bounds(index)
+		results = append(results, bs.bucketDatum(lower, upper, state))
+	}
+
+	bs.bucketedSource = datum.NewSliceStream(results)
+
+	return nil
+}
+
+// isTimeBucketing determines whether the field should be treated as a time.
+// This is always the case when bucketing by a duration. When splitting into N
+// buckets, it is decided by the first value of the field that is either a
+// number or a time.
+func (bs *BucketStream) isTimeBucketing(datums []datum.Datum) bool {
+	if bs.Duration > 0 {
+		return true
+	}
+
+	if bs.NumBuckets == 0 {
+		return false
+	}
+
+	for _, d := range datums {
+		fieldValue, ok := getField(d, bs.Field)
+		if !ok {
+			continue
+		}
+
+		if _, ok := convertPotentialNumber(fieldValue); ok {
+			return false
+		}
+
+		if _, ok := convertPotentialTime(fieldValue); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bucketNumbers places the datums into buckets by the number in the field.
+func (bs *BucketStream) bucketNumbers(datums []datum.Datum) (*bucketing, error) {
+	b := &bucketing{}
+	points := make([]float64, 0, len(datums))
+	for _, d := range datums {
+		fieldValue, ok := getField(d, bs.Field)
+		if !ok {
+			// If the field doesn't exist, ignore the document.
+			continue
+		}
+
+		point, ok := convertPotentialNumber(fieldValue)
+		if !ok {
+			// Ditto for values we can't place on the number line.
+			continue
+		}
+
+		points = append(points, point)
+		b.datums = append(b.datums, d)
+	}
+
+	origin, width := bs.originAndWidth(points)
+	for _, point := range points {
+		index := 0.0
+		if width != 0 {
+			index = math.Floor((point - origin) / width)
+		}
+		// The negation also catches NaN, e.g. from the width overflowing.
+		if !(math.Abs(index) <= maxBucketIndex) {
+			return nil, fmt.Errorf("%w: %v is too far from %v for buckets of %v", ErrTooManyBuckets, point, origin, width)
+		}
+
+		b.indices = append(b.indices, bs.clampIndex(int64(index)))
+	}
+
+	b.spread = width > 0
+	b.bounds = func(index int64) (interface{}, interface{}) {
+		lower := origin + float64(index)*width
+		return lower, lower + width
+	}
+
+	return b, nil
+}
+
+// originAndWidth returns where the buckets of numbers begin and how wide each
+// one is. Fixed-width buckets are aligned to zero.
+func (bs *BucketStream) originAndWidth(points []float64) (float64, float64) {
+	if bs.Width > 0 {
+		return 0, bs.Width
+	}
+
+	if len(points) == 0 {
+		return 0, 0
+	}
+
+	lowest, highest := points[0], points[0]
+	for _, point := range points {
+		lowest = math.Min(lowest, point)
+		highest = math.Max(highest, point)
+	}
+
+	return lowest, (highest - lowest) / float64(bs.NumBuckets)
+}
+
+// bucketTimes places the datums into buckets by the time in the field. This is
+// done in whole nanoseconds, so the bounds of the buckets are exact.
+func (bs *BucketStream) bucketTimes(datums []datum.Datum) (*bucketing, error) {
+	b := &bucketing{}
+	points := make([]time.Time, 0, len(datums))
+	for _, d := range datums {
+		fieldValue, ok := getField(d, bs.Field)
+		if !ok {
+			continue
+		}
+
+		point, ok := convertPotentialTime(fieldValue)
+		if !ok {
+			continue
+		}
+
+		points = append(points, point)
+		b.datums = append(b.datums, d)
+	}
+
+	origin, width, err := bs.timeOriginAndWidth(points)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		if width == 0 {
+			b.indices = append(b.indices, 0)
+			continue
+		}
+
+		sinceOrigin := point.Sub(origin)
+		if sinceOrigin == math.MaxInt64 || sinceOrigin == math.MinInt64 {
+			// Sub saturates, so the time may be further away than this.
+			return nil, fmt.Errorf("%w: %v is too far from %v", ErrTooManyBuckets, point, origin)
+		}
+
+		index := sinceOrigin / width
+		if sinceOrigin%width < 0 {
+			// Round towards the start of time, rather than zero.
+			index--
+		}
+		b.indices = append(b.indices, bs.clampIndex(int64(index)))
+	}
+
+	b.spread = width > 0
+	b.bounds = func(index int64) (interface{}, interface{}) {
+		lower := origin.Add(time.Duration(index) * width)
+		return lower.UTC().Format(time.RFC3339Nano), lower.Add(width).UTC().Format(time.RFC3339Nano)
+	}
+
+	return b, nil
+}
+
+// timeOriginAndWidth is like originAndWidth, but for times. Buckets of a
+// duration are aligned to the epoch, so that e.g. 1m buckets start on the
+// minute.
+func (bs *BucketStream) timeOriginAndWidth(points []time.Time) (time.Time, time.Duration, error) {
+	if bs.Duration > 0 {
+		return time.Unix(0, 0), bs.Duration, nil
+	}
+
+	if len(points) == 0 {
+		return time.Time{}, 0, nil
+	}
+
+	lowest, highest := points[0], points[0]
+	for _, point := range points {
+		if point.Before(lowest) {
+			lowest = point
+		}
+		if point.After(highest) {
+			highest = point
+		}
+	}
+
+	span := highest.Sub(lowest)
+	if span == math.MaxInt64 {
+		return time.Time{}, 0, fmt.Errorf("%w: the times span too long to split", ErrTooManyBuckets)
+	}
+
+	// Round the width up, so that the buckets cover the highest time.
+	numBuckets := time.Duration(bs.NumBuckets)
+	width := span / numBuckets
+	if span%numBuckets != 0 {
+		width++
+	}
+
+	return lowest, width, nil
+}
+
+// clampIndex keeps the index within the buckets when splitting into N of them.
+func (bs *BucketStream) clampIndex(index int64) int64 {
+	if bs.NumBuckets > 0 && index >= int64(bs.NumBuckets) {
+		// The highest value sits exactly on the upper boundary of the last
+		// bucket, but should still be counted in it.
+		return int64(bs.NumBuckets) - 1
+	}
+
+	return index
+}
+
+// indicesToEmit returns the indices of the buckets to emit, in order. Empty
+// buckets between non-empty ones are included, so the result can be read as a
+// histogram.
+func (bs *BucketStream) indicesToEmit(buckets map[int64]*bucketState, spread bool) ([]int64, error) {
+	// When every value is identical there is no range to split, so there is
+	// just the one bucket.
+	if bs.NumBuckets > 0 && len(buckets) > 0 && spread {
+		indices := make([]int64, bs.NumBuckets)
+		for i := range indices {
+			indices[i] = int64(i)
+		}
+		return indices, nil
+	}
+
+	indices := make([]int64, 0, len(buckets))
+	for index := range buckets {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	if len(indices) == 0 {
+		return indices, nil
+	}
+
+	// The difference between two int64s can overflow an int64, but not a
+	// uint64.
+	first, last := indices[0], indices[len(indices)-1]
+	if uint64(last)-uint64(first) >= maxFilledBuckets {
+		return nil, fmt.Errorf("%w: the values span more than %d buckets", ErrTooManyBuckets, maxFilledBuckets)
+	}
+
+	filled := make([]int64, 0, last-first+1)
+	for index := first; index <= last; index++ {
+		filled = append(filled, index)
+	}
+
+	return filled, nil
+}
+
+func (bs *BucketStream) newBucketState() *bucketState {
+	aggregates := make([]aggregator, len(bs.Aggregates))
+	for i, agg := range bs.Aggregates {
+		aggregates[i] = newAggregator(agg.AggFunc)
+	}
+
+	return &bucketState{aggregates: aggregates}
+}
+
+func (bs *BucketStream) bucketDatum(lower, upper interface{}, state *bucketState) datum.Datum {
+	d := datum.Datum{
+		"lower": lower,
+		"upper": upper,
+		"count": state.count,
+	}
+
+	for i, agg := range bs.Aggregates {
+		d[agg.As] = state.aggregates[i].aggregate()
+	}
+
+	return d
+}
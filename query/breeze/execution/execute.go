@@ -1,14 +1,22 @@
 package execution
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/utagai/look/datum"
 	"github.com/utagai/look/query/breeze"
 )
 
-// Execute executes the given series of stages on the given datum stream.
-func Execute(stream datum.Stream, stages []breeze.Stage) (datum.Stream, error) {
+// Execute executes the given series of stages on the given datum stream. The
+// stages do their work lazily, as the returned stream is read, so it is the
+// context given to the stream's Next() that cancels the execution. The context
+// given here only stops us from setting up a query that is already cancelled.
+func Execute(ctx context.Context, stream datum.Stream, stages []breeze.Stage) (datum.Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	for _, stage := range stages {
 		var newStream datum.Stream
 		switch ts := stage.(type) {
@@ -20,11 +28,27 @@ func Execute(stream datum.Stream, stages []breeze.Stage) (datum.Stream, error) {
 			newStream = executeGroup(ts, stream)
 		case *breeze.Map:
 			newStream = executeMap(ts, stream)
+		case *breeze.Lookup:
+			newStream = executeLookup(ts, stream)
+		case *breeze.Window:
+			newStream = executeWindow(ts, stream)
+		case *breeze.Bucket:
+			newStream = executeBucket(ts, stream)
+		case *breeze.Top:
+			newStream = executeTop(ts, stream)
+		case *breeze.Sample:
+			newStream = executeSample(ts, stream)
+		case *breeze.Rename:
+			newStream = executeRename(ts, stream)
+		case *breeze.Unset:
+			newStream = executeUnset(ts, stream)
+		case *breeze.Select:
+			newStream = executeSelect(ts, stream)
 		default:
 			return nil, fmt.Errorf("unrecognized query stage: %q", stage.Name())
 		}
 
-		return Execute(newStream, stages[1:])
+		return Execute(ctx, newStream, stages[1:])
 	}
 
 	return stream, nil
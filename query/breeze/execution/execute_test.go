@@ -1,7 +1,12 @@
 package execution_test
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/utagai/look/datum"
@@ -52,7 +57,7 @@ func runExecutionTestCase(t *testing.T, tc executionTestCase) {
 	stages, err := parser.Parse()
 	require.NoError(t, err)
 
-	result, err := execution.Execute(datum.NewSliceStream(tc.input), stages)
+	result, err := execution.Execute(context.Background(), datum.NewSliceStream(tc.input), stages)
 	if tc.expectedExecErr != nil {
 		require.Error(t, err)
 		require.ErrorIs(t, err, tc.expectedExecErr)
@@ -60,7 +65,7 @@ func runExecutionTestCase(t *testing.T, tc executionTestCase) {
 	}
 	require.NoError(t, err)
 
-	actualDatums, err := datum.StreamToSlice(result)
+	actualDatums, err := datum.StreamToSlice(context.Background(), result)
 	if tc.expectedStreamErr != nil {
 		require.Error(t, err)
 		require.ErrorIs(t, err, tc.expectedStreamErr)
@@ -1853,6 +1858,87 @@ func TestAggregationsUnsupportedTypes(t *testing.T) {
 	runExecutionTestCases(t, tcs)
 }
 
+func TestGroupBy(t *testing.T) {
+	tcs := []executionTestCase{
+		{
+			name: "groups report their key, in order",
+			input: []datum.Datum{
+				{"g": "b", "i": 1},
+				{"g": "a", "i": 2},
+				{"g": "b", "i": 3},
+				{"i": 4},
+			},
+			query: "group by .g sum .i",
+			expectedResult: []datum.Datum{
+				{"g": "a", "i": float64(2)},
+				{"g": "b", "i": float64(4)},
+			},
+		},
+		{
+			name: "numeric groups are in numeric order",
+			input: []datum.Datum{
+				{"g": 10, "i": 1},
+				{"g": 9, "i": 2},
+				{"g": 100, "i": 3},
+			},
+			query: "group by .g count .i",
+			expectedResult: []datum.Datum{
+				{"g": 9, "i": uint(1)},
+				{"g": 10, "i": uint(1)},
+				{"g": 100, "i": uint(1)},
+			},
+		},
+		{
+			name: "equal numbers group together, reporting the first seen",
+			input: []datum.Datum{
+				{"g": 1, "i": 1},
+				{"g": 1.0, "i": 2},
+			},
+			query: "group by .g count .i",
+			expectedResult: []datum.Datum{
+				{"g": 1, "i": uint(2)},
+			},
+		},
+		{
+			name: "the aggregate of the group-by field is named apart from the key",
+			input: []datum.Datum{
+				{"g": "a"},
+				{"g": "b"},
+				{"g": "a"},
+			},
+			query: "group by .g count .g",
+			expectedResult: []datum.Datum{
+				{"g": "a", "count_g": uint(2)},
+				{"g": "b", "count_g": uint(1)},
+			},
+		},
+		{
+			name: "the aggregate of the parent of the key is named apart from it",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}},
+				{"a": map[string]interface{}{"b": 1}},
+			},
+			query: "group by .a.b count .a",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}, "count_a": uint(2)},
+			},
+		},
+		{
+			name: "nested keys are reported as nested objects",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": "x"}, "i": 1},
+				{"a": map[string]interface{}{"b": "x"}, "i": 2},
+			},
+			query: "group by .a.b sum .i",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": "x"}, "i": float64(3)},
+			},
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
 func TestFunctions(t *testing.T) {
 	tcs := []executionTestCase{
 		{
@@ -1947,3 +2033,766 @@ func TestFunctions(t *testing.T) {
 
 	runExecutionTestCases(t, tcs)
 }
+
+func TestLookup(t *testing.T) {
+	usersPath := filepath.Join(t.TempDir(), "users.json")
+	users := `[{"id": 1, "name": "alice"}, {"id": 2, "name": "bob"}, {"id": 2, "name": "bobby"}, {"name": "nobody"}, {"id": null, "name": "nil"}]`
+	require.NoError(t, os.WriteFile(usersPath, []byte(users), 0o600))
+
+	newInput := func() []datum.Datum {
+		return []datum.Datum{
+			{
+				"user_id": 1,
+			},
+			{
+				"user_id": 2,
+			},
+			{
+				"user_id": 3,
+			},
+			{
+				"other": 1,
+			},
+			{
+				"user_id": nil,
+			},
+		}
+	}
+
+	tcs := []executionTestCase{
+		{
+			name:  "inner lookup",
+			input: newInput(),
+			query: fmt.Sprintf(`lookup %q on .user_id = .id as user`, usersPath),
+			expectedResult: []datum.Datum{
+				{
+					"user_id": 1,
+					"user":    []interface{}{datum.Datum{"id": 1.0, "name": "alice"}},
+				},
+				{
+					"user_id": 2,
+					"user": []interface{}{
+						datum.Datum{"id": 2.0, "name": "bob"},
+						datum.Datum{"id": 2.0, "name": "bobby"},
+					},
+				},
+			},
+		},
+		{
+			name:  "left lookup",
+			input: newInput(),
+			query: fmt.Sprintf(`lookup left %q on .user_id = .id as user`, usersPath),
+			expectedResult: []datum.Datum{
+				{
+					"user_id": 1,
+					"user":    []interface{}{datum.Datum{"id": 1.0, "name": "alice"}},
+				},
+				{
+					"user_id": 2,
+					"user": []interface{}{
+						datum.Datum{"id": 2.0, "name": "bob"},
+						datum.Datum{"id": 2.0, "name": "bobby"},
+					},
+				},
+				{
+					"user_id": 3,
+					"user":    []interface{}{},
+				},
+				{
+					"other": 1,
+					"user":  []interface{}{},
+				},
+				{
+					"user_id": nil,
+					"user":    []interface{}{},
+				},
+			},
+		},
+		{
+			name:  "anti lookup",
+			input: newInput(),
+			query: fmt.Sprintf(`lookup anti %q on .user_id = .id as user`, usersPath),
+			expectedResult: []datum.Datum{
+				{
+					"user_id": 3,
+				},
+				{
+					"other": 1,
+				},
+				{
+					"user_id": nil,
+				},
+			},
+		},
+		{
+			name:              "lookup against missing source",
+			input:             newInput(),
+			query:             fmt.Sprintf(`lookup %q on .user_id = .id as user`, filepath.Join(t.TempDir(), "nope.json")),
+			expectedStreamErr: os.ErrNotExist,
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
+func TestLookupRereadsChangedSource(t *testing.T) {
+	usersPath := filepath.Join(t.TempDir(), "users.json")
+	input := []datum.Datum{{"user_id": 1}}
+	query := fmt.Sprintf(`lookup %q on .user_id = .id as user`, usersPath)
+
+	require.NoError(t, os.WriteFile(usersPath, []byte(`[{"id": 1, "name": "alice"}]`), 0o600))
+	runExecutionTestCase(t, executionTestCase{
+		input: input,
+		query: query,
+		expectedResult: []datum.Datum{
+			{"user_id": 1, "user": []interface{}{datum.Datum{"id": 1.0, "name": "alice"}}},
+		},
+	})
+
+	require.NoError(t, os.WriteFile(usersPath, []byte(`[{"id": 1, "name": "alicia"}]`), 0o600))
+	// The file may well be rewritten within the resolution of its modification
+	// time, so move it on explicitly.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(usersPath, later, later))
+	runExecutionTestCase(t, executionTestCase{
+		input: input,
+		query: query,
+		expectedResult: []datum.Datum{
+			{"user_id": 1, "user": []interface{}{datum.Datum{"id": 1.0, "name": "alicia"}}},
+		},
+	})
+}
+
+func TestWindow(t *testing.T) {
+	newInput := func() []datum.Datum {
+		return []datum.Datum{
+			{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z"},
+			{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z"},
+			{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z"},
+			{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z"},
+			{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z"},
+		}
+	}
+
+	tcs := []executionTestCase{
+		{
+			name:  "running sum",
+			input: newInput(),
+			query: "window sum x as total",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z", "total": 1.0},
+				{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z", "total": 11.0},
+				{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z", "total": 13.0},
+				{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z", "total": 16.0},
+				{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z", "total": 36.0},
+			},
+		},
+		{
+			name:  "partitioned running sum",
+			input: newInput(),
+			query: "window by svc sum x as total",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z", "total": 1.0},
+				{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z", "total": 10.0},
+				{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z", "total": 3.0},
+				{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z", "total": 6.0},
+				{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z", "total": 30.0},
+			},
+		},
+		{
+			name:  "rolling average over rows",
+			input: newInput(),
+			query: "window by svc avg x rows 2",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z", "avg_x": 1.0},
+				{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z", "avg_x": 10.0},
+				{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z", "avg_x": 1.5},
+				{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z", "avg_x": 2.5},
+				{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z", "avg_x": 15.0},
+			},
+		},
+		{
+			name:  "count over time range",
+			input: newInput(),
+			query: "window count x range 1m on t",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z", "count_x": uint(1)},
+				{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z", "count_x": uint(2)},
+				{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z", "count_x": uint(2)},
+				{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z", "count_x": uint(2)},
+				{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z", "count_x": uint(2)},
+			},
+		},
+		{
+			name:  "row number, lag and lead",
+			input: newInput(),
+			query: "window by svc row_number as n | window by svc lag x as prev | window lead x 2 as next",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "t": "2021-01-01T00:00:00Z", "n": 1, "prev": nil, "next": 2},
+				{"svc": "b", "x": 10, "t": "2021-01-01T00:00:30Z", "n": 1, "prev": nil, "next": 3},
+				{"svc": "a", "x": 2, "t": "2021-01-01T00:01:00Z", "n": 2, "prev": 1, "next": 20},
+				{"svc": "a", "x": 3, "t": "2021-01-01T00:01:30Z", "n": 3, "prev": 2, "next": nil},
+				{"svc": "b", "x": 20, "t": "2021-01-01T00:02:00Z", "n": 2, "prev": 10, "next": nil},
+			},
+		},
+		{
+			name: "missing partition field gets its own partition",
+			input: []datum.Datum{
+				{"svc": "a", "x": 1},
+				{"x": 2},
+				{"svc": "a", "x": 3},
+				{"x": 4},
+			},
+			query: "window by svc sum x as total",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 1, "total": 1.0},
+				{"x": 2, "total": 2.0},
+				{"svc": "a", "x": 3, "total": 4.0},
+				{"x": 4, "total": 6.0},
+			},
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
+func TestBucket(t *testing.T) {
+	tcs := []executionTestCase{
+		{
+			name: "bucket numbers by width fills empty buckets",
+			input: []datum.Datum{
+				{"latency": 10},
+				{"latency": 49.9},
+				{"latency": 50},
+				{"latency": 170},
+				{"latency": "slow"},
+				{"other": 1},
+			},
+			query: "bucket latency by 50",
+			expectedResult: []datum.Datum{
+				{"lower": 0.0, "upper": 50.0, "count": uint(2)},
+				{"lower": 50.0, "upper": 100.0, "count": uint(1)},
+				{"lower": 100.0, "upper": 150.0, "count": uint(0)},
+				{"lower": 150.0, "upper": 200.0, "count": uint(1)},
+			},
+		},
+		{
+			name: "bucket with aggregates",
+			input: []datum.Datum{
+				{"latency": 10, "size": 1},
+				{"latency": 20, "size": 3},
+				{"latency": 60, "size": 5},
+			},
+			query: "bucket latency by 50 avg size, max size",
+			expectedResult: []datum.Datum{
+				{"lower": 0.0, "upper": 50.0, "count": uint(2), "avg_size": 2.0, "max_size": 3},
+				{"lower": 50.0, "upper": 100.0, "count": uint(1), "avg_size": 5.0, "max_size": 5},
+			},
+		},
+		{
+			name: "bucket times by duration",
+			input: []datum.Datum{
+				{"t": "2021-01-01T00:00:10Z"},
+				{"t": "2021-01-01T00:00:50Z"},
+				{"t": "2021-01-01T00:02:00Z"},
+			},
+			query: "bucket t by 1m",
+			expectedResult: []datum.Datum{
+				{"lower": "2021-01-01T00:00:00Z", "upper": "2021-01-01T00:01:00Z", "count": uint(2)},
+				{"lower": "2021-01-01T00:01:00Z", "upper": "2021-01-01T00:02:00Z", "count": uint(0)},
+				{"lower": "2021-01-01T00:02:00Z", "upper": "2021-01-01T00:03:00Z", "count": uint(1)},
+			},
+		},
+		{
+			name: "bucket into equal-width buckets",
+			input: []datum.Datum{
+				{"x": 0},
+				{"x": 1},
+				{"x": 3},
+				{"x": 4},
+			},
+			query: "bucket x into 2",
+			expectedResult: []datum.Datum{
+				{"lower": 0.0, "upper": 2.0, "count": uint(2)},
+				{"lower": 2.0, "upper": 4.0, "count": uint(2)},
+			},
+		},
+		{
+			name: "bucket into buckets with identical values",
+			input: []datum.Datum{
+				{"x": 5},
+				{"x": 5},
+			},
+			query: "bucket x into 3",
+			expectedResult: []datum.Datum{
+				{"lower": 5.0, "upper": 5.0, "count": uint(2)},
+			},
+		},
+		{
+			name:           "bucket no datums",
+			input:          []datum.Datum{},
+			query:          "bucket x into 3",
+			expectedResult: []datum.Datum{},
+		},
+		{
+			name: "bucket times into equal-width buckets to the nanosecond",
+			input: []datum.Datum{
+				{"t": "2021-01-01T00:00:00Z"},
+				{"t": "2021-01-01T00:01:00.003Z"},
+			},
+			query: "bucket t into 3",
+			expectedResult: []datum.Datum{
+				{"lower": "2021-01-01T00:00:00Z", "upper": "2021-01-01T00:00:20.001Z", "count": uint(1)},
+				{"lower": "2021-01-01T00:00:20.001Z", "upper": "2021-01-01T00:00:40.002Z", "count": uint(0)},
+				{"lower": "2021-01-01T00:00:40.002Z", "upper": "2021-01-01T00:01:00.003Z", "count": uint(1)},
+			},
+		},
+		{
+			name: "bucket values too far apart",
+			input: []datum.Datum{
+				{"x": 0},
+				{"x": 1e19},
+			},
+			query:             "bucket x by 1",
+			expectedStreamErr: execution.ErrTooManyBuckets,
+		},
+		{
+			name: "bucket by a width too small for the values",
+			input: []datum.Datum{
+				{"x": 0},
+				{"x": 10},
+			},
+			query:             "bucket x by 0.0001",
+			expectedStreamErr: execution.ErrTooManyBuckets,
+		},
+		{
+			name: "bucket times by a duration too small for them",
+			input: []datum.Datum{
+				{"t": "1700-01-01T00:00:00Z"},
+				{"t": "2200-01-01T00:00:00Z"},
+			},
+			query:             "bucket t by 1ns",
+			expectedStreamErr: execution.ErrTooManyBuckets,
+		},
+		{
+			name:              "bucket into too many buckets",
+			input:             []datum.Datum{{"x": 0}, {"x": 1}},
+			query:             "bucket x into 100000",
+			expectedStreamErr: execution.ErrTooManyBuckets,
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
+func TestTop(t *testing.T) {
+	newInput := func() []datum.Datum {
+		return []datum.Datum{
+			{"svc": "a", "x": 5},
+			{"svc": "b", "x": 1},
+			{"svc": "a", "x": 9},
+			{"svc": "b", "x": 7},
+			{"svc": "a", "x": 2},
+			{"svc": "a", "x": 9, "dup": true},
+			{"svc": "b"},
+		}
+	}
+
+	tcs := []executionTestCase{
+		{
+			name:  "top k descending",
+			input: newInput(),
+			query: "top 3 by x",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 9},
+				{"svc": "a", "x": 9, "dup": true},
+				{"svc": "b", "x": 7},
+			},
+		},
+		{
+			name:  "top k ascending",
+			input: newInput(),
+			query: "top 2 by x asc",
+			expectedResult: []datum.Datum{
+				{"svc": "b", "x": 1},
+				{"svc": "a", "x": 2},
+			},
+		},
+		{
+			name:  "top k larger than input",
+			input: newInput(),
+			query: "top 100 by x",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 9},
+				{"svc": "a", "x": 9, "dup": true},
+				{"svc": "b", "x": 7},
+				{"svc": "a", "x": 5},
+				{"svc": "a", "x": 2},
+				{"svc": "b", "x": 1},
+			},
+		},
+		{
+			name:  "top k per group",
+			input: newInput(),
+			query: "top 1 by x per svc",
+			expectedResult: []datum.Datum{
+				{"svc": "a", "x": 9},
+				{"svc": "b", "x": 7},
+			},
+		},
+		{
+			name:           "top k no datums",
+			input:          []datum.Datum{},
+			query:          "top 1 by x",
+			expectedResult: []datum.Datum{},
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
+// Samples are random, so rather than checking for exact results like the
+// other execution tests, these check the properties of the samples.
+func TestSample(t *testing.T) {
+	input := make([]datum.Datum, 1000)
+	for i := range input {
+		input[i] = datum.Datum{"i": i}
+	}
+
+	execute := func(t *testing.T, query string) []datum.Datum {
+		stages, err := breeze.NewParser(query).Parse()
+		require.NoError(t, err)
+		result, err := execution.Execute(context.Background(), datum.NewSliceStream(input), stages)
+		require.NoError(t, err)
+		datums, err := datum.StreamToSlice(context.Background(), result)
+		require.NoError(t, err)
+		return datums
+	}
+
+	requireOrdered := func(t *testing.T, datums []datum.Datum) {
+		for i := 1; i < len(datums); i++ {
+			require.Less(t, datums[i-1]["i"], datums[i]["i"])
+		}
+	}
+
+	t.Run("reservoir sample has the requested size", func(t *testing.T) {
+		datums := execute(t, "sample 10")
+		require.Len(t, datums, 10)
+		requireOrdered(t, datums)
+	})
+
+	t.Run("reservoir sample larger than input returns everything", func(t *testing.T) {
+		require.Equal(t, input, execute(t, "sample 5000"))
+	})
+
+	t.Run("seeded reservoir samples are reproducible", func(t *testing.T) {
+		require.Equal(t, execute(t, "sample 10 seed 7"), execute(t, "sample 10 seed 7"))
+		require.NotEqual(t, execute(t, "sample 10 seed 7"), execute(t, "sample 10 seed 8"))
+	})
+
+	t.Run("bernoulli sample keeps roughly the requested percentage", func(t *testing.T) {
+		datums := execute(t, "sample 10% seed 1")
+		require.InDelta(t, 100, len(datums), 40)
+		requireOrdered(t, datums)
+	})
+
+	t.Run("seeded bernoulli samples are reproducible", func(t *testing.T) {
+		require.Equal(t, execute(t, "sample 10% seed 7"), execute(t, "sample 10% seed 7"))
+	})
+
+	t.Run("bernoulli sample of 100 percent keeps everything", func(t *testing.T) {
+		require.Equal(t, input, execute(t, "sample 100%"))
+	})
+}
+
+func TestFieldPaths(t *testing.T) {
+	tcs := []executionTestCase{
+		{
+			name: "filter on nested field",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}},
+				{"a": map[string]interface{}{"b": 2}},
+				{"a": 1},
+			},
+			query: "filter .a.b = 2",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": 2}},
+			},
+		},
+		{
+			name: "literal dotted field takes priority",
+			input: []datum.Datum{
+				{"a.b": 1, "a": map[string]interface{}{"b": 2}},
+			},
+			query: "filter .a.b = 1",
+			expectedResult: []datum.Datum{
+				{"a.b": 1, "a": map[string]interface{}{"b": 2}},
+			},
+		},
+		{
+			name: "sort on nested field",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": 2}},
+				{"a": map[string]interface{}{"b": 1}},
+			},
+			query: "sort .a.b",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}},
+				{"a": map[string]interface{}{"b": 2}},
+			},
+		},
+		{
+			name: "map into nested field",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}},
+				{},
+				{"a": 1},
+			},
+			query: "map .a.c = 2",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1, "c": 2.0}},
+				{"a": map[string]interface{}{"c": 2.0}},
+				// The intermediate is not an object, so the assignment is skipped.
+				{"a": 1},
+			},
+		},
+		{
+			name: "rename",
+			input: []datum.Datum{
+				{"old": 1, "other": 2},
+				{"other": 2},
+				{"old": 1, "a": "not an object"},
+			},
+			query: "rename .old as .new | rename .old as .a.b",
+			expectedResult: []datum.Datum{
+				{"new": 1, "other": 2},
+				{"other": 2},
+				{"new": 1, "a": "not an object"},
+			},
+		},
+		{
+			name: "rename into and out of nested fields",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": 1}},
+			},
+			query: "rename .a.b as .c.d",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{}, "c": map[string]interface{}{"d": 1}},
+			},
+		},
+		{
+			name: "rename a nested field as its ancestor",
+			input: []datum.Datum{
+				{"a": map[string]interface{}{"b": map[string]interface{}{"b": 5}}},
+			},
+			query: "rename .a.b as .a",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"b": 5}},
+			},
+		},
+		{
+			name: "rename into a field that isn't an object",
+			input: []datum.Datum{
+				{"x": 1, "a": "not an object"},
+			},
+			query: "rename .x as .a.b",
+			expectedResult: []datum.Datum{
+				{"x": 1, "a": "not an object"},
+			},
+		},
+		{
+			name: "unset",
+			input: []datum.Datum{
+				{"tmp": 1, "a": map[string]interface{}{"b": 1, "c": 2}},
+				{"a": 1},
+			},
+			query: "unset .tmp, .a.b",
+			expectedResult: []datum.Datum{
+				{"a": map[string]interface{}{"c": 2}},
+				{"a": 1},
+			},
+		},
+		{
+			name: "select",
+			input: []datum.Datum{
+				{"x": 1, "y": 2, "a": map[string]interface{}{"b": 1, "c": 2}},
+				{"y": 2},
+			},
+			query: "select .x, .a.b",
+			expectedResult: []datum.Datum{
+				{"x": 1, "a": map[string]interface{}{"b": 1}},
+				{},
+			},
+		},
+	}
+
+	runExecutionTestCases(t, tcs)
+}
+
+func TestSourceDataIsNotMutated(t *testing.T) {
+	newInput := func() []datum.Datum {
+		return []datum.Datum{
+			{"svc": "a", "x": 1, "a": map[string]interface{}{"b": 1}},
+			{"svc": "b", "x": 2, "a": datum.Datum{"b": 2, "c": map[string]interface{}{"d": 3}}},
+		}
+	}
+
+	queries := []string{
+		"map .x = .x + 1",
+		"map .a.b = 5",
+		"map .a.c.d = 5",
+		"map .a.e.f = 5",
+		"rename .x as .y",
+		"rename .a.b as .z",
+		"rename .a.c.d as .a.b",
+		"unset .x",
+		"unset .a.b",
+		"unset .a.c.d",
+		"select .a.b | map .a.b = 5",
+		"window sum .x",
+		"window by .svc row_number as .a.n",
+		"map .x = 3 | rename .x as .y | unset .svc",
+	}
+
+	for _, q := range queries {
+		t.Run(q, func(t *testing.T) {
+			parser := breeze.NewParser(q)
+			stages, err := parser.Parse()
+			require.NoError(t, err)
+
+			input := newInput()
+			result, err := execution.Execute(context.Background(), datum.NewSliceStream(input), stages)
+			require.NoError(t, err)
+			_, err = datum.StreamToSlice(context.Background(), result)
+			require.NoError(t, err)
+
+			require.Equal(t, newInput(), input)
+		})
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	input := make([]datum.Datum, 1000)
+	for i := range input {
+		input[i] = datum.Datum{"i": i, "g": i % 10, "t": i}
+	}
+
+	queries := []string{
+		"filter .i > 5",
+		"sort .i",
+		"group sum .i",
+		"group by .g sum .i",
+		"map .j = .i",
+		"window sum .i",
+		"bucket .i by 10",
+		"top 5 by .i",
+		"sample 5",
+		"sample 50%",
+		"rename .i as .j",
+		"unset .i",
+		"sort .i | group by .g sum .i | filter .i > 5",
+	}
+
+	for _, q := range queries {
+		t.Run(q, func(t *testing.T) {
+			parser := breeze.NewParser(q)
+			stages, err := parser.Parse()
+			require.NoError(t, err)
+
+			_, err = execution.Execute(cancelledContext(), datum.NewSliceStream(input), stages)
+			require.ErrorIs(t, err, context.Canceled)
+
+			// Queries do their work lazily, so cancelling the context after Execute
+			// returns should still stop the query.
+			ctx, cancel := context.WithCancel(context.Background())
+			result, err := execution.Execute(ctx, datum.NewSliceStream(input), stages)
+			require.NoError(t, err)
+			cancel()
+			_, err = datum.StreamToSlice(ctx, result)
+			require.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestExecuteProfiled(t *testing.T) {
+	input := make([]datum.Datum, 10)
+	for i := range input {
+		input[i] = datum.Datum{"i": i, "even": i%2 == 0}
+	}
+
+	parser := breeze.NewParser("filter .even = true | sort .i | top 2 by .i")
+	stages, err := parser.Parse()
+	require.NoError(t, err)
+
+	result, profile, err := execution.ExecuteProfiled(context.Background(), datum.NewSliceStream(input), stages)
+	require.NoError(t, err)
+	datums, err := datum.StreamToSlice(context.Background(), result)
+	require.NoError(t, err)
+	require.Len(t, datums, 2)
+
+	stats := profile.Stages()
+	require.Len(t, stats, 3)
+	for i, expected := range []execution.StageStats{
+		{Stage: "filter", RowsIn: 10, RowsOut: 5},
+		{Stage: "sort", RowsIn: 5, RowsOut: 5},
+		{Stage: "top", RowsIn: 5, RowsOut: 2},
+	} {
+		require.GreaterOrEqual(t, stats[i].Elapsed, time.Duration(0))
+		stats[i].Elapsed = 0
+		require.Equal(t, expected, stats[i])
+	}
+}
+
+func TestMatchSpans(t *testing.T) {
+	d := datum.Datum{
+		"title":   "foo bar foo",
+		"id":      7,
+		"escaped": "foo\tbar",
+		"nested":  map[string]interface{}{"name": "xfoox"},
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected []string
+	}{
+		{query: `filter .title contains "foo"`, expected: []string{"foo", "foo"}},
+		{query: `filter .id = 7`, expected: []string{`"id":7`}},
+		{query: `filter regex(.title, "ba.")`, expected: []string{"bar"}},
+		{query: `filter .nested.name contains "foo"`, expected: []string{"foo"}},
+		// The offsets into an escaped string are lost, so the whole value is used.
+		{query: `filter .escaped contains "bar"`, expected: []string{`"foo\tbar"`}},
+		{query: `filter .title contains "baz"`, expected: []string{}},
+		{query: `filter .id = 7 | unset .id`, expected: []string{}},
+		{query: `sort .id`, expected: []string{}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			parser := breeze.NewParser(tc.query)
+			stages, err := parser.Parse()
+			require.NoError(t, err)
+
+			stream, err := execution.Execute(context.Background(), datum.NewSliceStream([]datum.Datum{d}), stages)
+			require.NoError(t, err)
+			datums, err := datum.StreamToSlice(context.Background(), stream)
+			require.NoError(t, err)
+			// Check the datum even if it was filtered out, since nothing in it
+			// should match then.
+			result := d
+			if len(datums) == 1 {
+				result = datums[0]
+			}
+
+			s := result.String()
+			matched := []string{}
+			for _, span := range execution.MatchSpans(stages, result) {
+				matched = append(matched, s[span.Start:span.End])
+			}
+			require.Equal(t, tc.expected, matched)
+		})
+	}
+}
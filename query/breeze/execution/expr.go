@@ -56,7 +56,7 @@ func evaluateValue(value breeze.Value, datum datum.Datum) (breeze.Concrete, erro
 }
 
 func evaluateFieldRef(fieldRef *breeze.FieldRef, datum datum.Datum) breeze.Concrete {
-	val, ok := datum[fieldRef.Field]
+	val, ok := getField(datum, fieldRef.Field)
 	if !ok {
 		return &breeze.Missing{}
 	}
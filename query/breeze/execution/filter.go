@@ -1,6 +1,8 @@
 package execution
 
 import (
+	"context"
+
 	"github.com/utagai/look/datum"
 	"github.com/utagai/look/query/breeze"
 )
@@ -19,11 +21,11 @@ type FilterStream struct {
 }
 
 // Next implements the datum.DatumStream interface.
-func (fs *FilterStream) Next() (datum.Datum, error) {
+func (fs *FilterStream) Next(ctx context.Context) (datum.Datum, error) {
 	// Keep iterating the stream until something passes the checks.
 outer:
 	for {
-		datum, err := fs.source.Next()
+		datum, err := fs.source.Next(ctx)
 		if err != nil {
 			return nil, err
 		}
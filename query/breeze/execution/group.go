@@ -1,8 +1,10 @@
 package execution
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/utagai/look/datum"
 	"github.com/utagai/look/query/breeze"
@@ -23,22 +25,34 @@ type GroupStream struct {
 }
 
 // Next implements the datum.DatumStream interface.
-func (ss *GroupStream) Next() (datum.Datum, error) {
+func (ss *GroupStream) Next(ctx context.Context) (datum.Datum, error) {
 	if ss.groupedSource == nil {
-		if err := ss.groupSource(); err != nil {
+		if err := ss.groupSource(ctx); err != nil {
 			return nil, fmt.Errorf("failed to group data: %w", err)
 		}
 	}
 
-	return ss.groupedSource.Next()
+	return ss.groupedSource.Next(ctx)
 }
 
-func (ss *GroupStream) groupSource() error {
-	sourcesToAggregate := ss.splitSource()
+func (ss *GroupStream) groupSource(ctx context.Context) error {
+	groupByFieldValues, sourcesToAggregate, err := ss.splitSource(ctx)
+	if err != nil {
+		return err
+	}
 
 	aggregateResults := make([]datum.Datum, len(sourcesToAggregate))
 	for i, source := range sourcesToAggregate {
-		aggregateResults[i] = ss.aggregateStream(source)
+		aggregateResults[i], err = ss.aggregateStream(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		if ss.GroupByField != nil {
+			// Report which group this is. The aggregate is named apart from the
+			// group-by field, so this can't overwrite it.
+			setField(aggregateResults[i], *ss.GroupByField, groupByFieldValues[i])
+		}
 	}
 
 	ss.groupedSource = datum.NewSliceStream(aggregateResults)
@@ -46,25 +60,38 @@ func (ss *GroupStream) groupSource() error {
 	return nil
 }
 
-func (ss *GroupStream) splitSource() []datum.Stream {
+// splitSource splits the source into a stream per group, ordered by the values
+// of the group-by field, which it returns alongside. The value of each group is
+// the first one seen of it, e.g. an int stays an int even though it groups
+// with equal floats. There is a single group, with no value, if there is no
+// group-by field.
+func (ss *GroupStream) splitSource(ctx context.Context) ([]interface{}, []datum.Stream, error) {
 	if ss.GroupByField == nil {
 		// If there isn't a group by condition then we are simply aggregating over
 		// the entire input, so return just the original input:
-		return []datum.Stream{ss.source}
+		return []interface{}{nil}, []datum.Stream{ss.source}, nil
 	}
 
 	// Otherwise, we need to split apart the input stream by the group by field
 	// and create N separate streams, each of which should then be independently
 	// aggregated over (we do not do the aggregation here).
 	table := newTable()
-	for sourceDatum, err := ss.source.Next(); err == nil; sourceDatum, err = ss.source.Next() {
-		groupByFieldValue, ok := sourceDatum[*ss.GroupByField]
+	var groupByFieldValues []interface{}
+	for {
+		sourceDatum, err := ss.source.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+
+		groupByFieldValue, ok := getField(sourceDatum, *ss.GroupByField)
 		if !ok {
 			// If the field doesn't exist, ignore the document.
 			continue
 		}
 
-		aggregateFieldValue, ok := sourceDatum[ss.AggregateField]
+		aggregateFieldValue, ok := getField(sourceDatum, ss.AggregateField)
 		if !ok {
 			// If the aggregate field value for this doesn't exist, we should
 			// similarly also ignore it.
@@ -78,45 +105,36 @@ func (ss *GroupStream) splitSource() []datum.Stream {
 			)
 		} else {
 			table.Set(groupByFieldValue, []datum.Datum{{ss.AggregateField: aggregateFieldValue}})
+			groupByFieldValues = append(groupByFieldValues, groupByFieldValue)
 		}
 	}
 
-	groupByFieldValues := table.Keys()
+	// Order the groups, so that the output is stable, and e.g. numeric groups
+	// come out in order.
+	sort.SliceStable(groupByFieldValues, func(i, j int) bool {
+		return Compare(groupByFieldValues[i], groupByFieldValues[j]) == Lesser
+	})
 	splitSources := make([]datum.Stream, len(groupByFieldValues))
 	for i, groupByFieldValue := range groupByFieldValues {
 		aggregateFieldValues := table.Get(groupByFieldValue)
 		splitSources[i] = datum.NewSliceStream(aggregateFieldValues.([]datum.Datum))
 	}
 
-	return splitSources
+	return groupByFieldValues, splitSources, nil
 }
 
-func (ss *GroupStream) getAggregator() aggregator {
-	switch ss.AggFunc {
-	case breeze.AggFuncSum:
-		return &sum{}
-	case breeze.AggFuncAvg:
-		return &avg{}
-	case breeze.AggFuncCount:
-		return &count{}
-	case breeze.AggFuncMin:
-		return &min{}
-	case breeze.AggFuncMax:
-		return &max{}
-	case breeze.AggFuncMode:
-		return &mode{}
-	case breeze.AggFuncStdDev:
-		return &stddev{}
-	default:
-		panic(fmt.Sprintf("unrecognized aggregate function: %q", ss.AggFunc))
-	}
-}
+func (ss *GroupStream) aggregateStream(ctx context.Context, input datum.Stream) (datum.Datum, error) {
+	agg := newAggregator(ss.AggFunc)
 
-func (ss *GroupStream) aggregateStream(input datum.Stream) datum.Datum {
-	agg := ss.getAggregator()
+	for {
+		datum, err := input.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read data: %w", err)
+		}
 
-	for datum, err := input.Next(); err != io.EOF; datum, err = input.Next() {
-		fieldValue, ok := datum[ss.AggregateField]
+		fieldValue, ok := getField(datum, ss.AggregateField)
 		if !ok {
 			// If the field doesn't exist, ignore the document.
 			continue
@@ -125,6 +143,6 @@ func (ss *GroupStream) aggregateStream(input datum.Stream) datum.Datum {
 	}
 
 	return datum.Datum{
-		ss.AggregateField: agg.aggregate(),
-	}
+		ss.AggregateAs(): agg.aggregate(),
+	}, nil
 }
@@ -0,0 +1,160 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+// foreignSources caches the datums of the secondary sources of lookups. Queries
+// are run as they are typed, so each would otherwise read the source again.
+var foreignSources = &sourceCache{sources: map[string]cachedSource{}}
+
+// sourceCache caches the datums of files by path, for as long as the files are
+// unchanged.
+type sourceCache struct {
+	mu      sync.Mutex
+	sources map[string]cachedSource
+}
+
+type cachedSource struct {
+	// info is the info of the file when it was read.
+	info   os.FileInfo
+	datums []datum.Datum
+}
+
+// read returns the datums of the file at the path, reading it unless it is
+// unchanged since it was last read.
+func (c *sourceCache) read(path string) ([]datum.Datum, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.sources[path]
+	c.mu.Unlock()
+	if ok && cached.info.ModTime().Equal(info.ModTime()) && cached.info.Size() == info.Size() {
+		return cached.datums, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	datums, err := datum.ReadJSON(f)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.sources[path] = cachedSource{info: info, datums: datums}
+	c.mu.Unlock()
+
+	return datums, nil
+}
+
+func executeLookup(lookup *breeze.Lookup, stream datum.Stream) *LookupStream {
+	return &LookupStream{
+		Lookup: lookup,
+		source: stream,
+	}
+}
+
+// LookupStream is an implementation of datum.Stream for the lookup stage.
+// It loads the entire secondary source into a table keyed on the foreign
+// field, and then enriches each datum of the source stream with its matches.
+type LookupStream struct {
+	*breeze.Lookup
+	source  datum.Stream
+	foreign *table
+}
+
+// Next implements the datum.DatumStream interface.
+func (ls *LookupStream) Next(ctx context.Context) (datum.Datum, error) {
+	if ls.foreign == nil {
+		if err := ls.loadForeign(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load lookup source %q: %w", ls.Source, err)
+		}
+	}
+
+	for {
+		datum, err := ls.source.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := ls.matchesFor(datum)
+		switch ls.Mode {
+		case breeze.LookupModeInner:
+			if len(matches) == 0 {
+				continue
+			}
+			datum = datum.Copy()
+			setField(datum, ls.As, matches)
+		case breeze.LookupModeLeft:
+			datum = datum.Copy()
+			setField(datum, ls.As, matches)
+		case breeze.LookupModeAnti:
+			if len(matches) != 0 {
+				continue
+			}
+		default:
+			panic(fmt.Sprintf("unrecognized lookup mode: %q", ls.Mode))
+		}
+
+		return datum, nil
+	}
+}
+
+func (ls *LookupStream) matchesFor(d datum.Datum) []interface{} {
+	matches := []interface{}{}
+	localValue, ok := getField(d, ls.LocalField)
+	if !ok || localValue == nil || !isTableKey(localValue) {
+		// Missing and null fields, and values that can't be hashed (arrays,
+		// objects) never match anything, as in a SQL join.
+		return matches
+	}
+
+	if foreignDatums, ok := ls.foreign.GetOK(localValue); ok {
+		matches = append(matches, foreignDatums.([]interface{})...)
+	}
+
+	return matches
+}
+
+func (ls *LookupStream) loadForeign(ctx context.Context) error {
+	foreignDatums, err := foreignSources.read(ls.Source)
+	if err != nil {
+		return err
+	}
+
+	foreign := newTable()
+	for _, foreignDatum := range foreignDatums {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		foreignValue, ok := getField(foreignDatum, ls.ForeignField)
+		if !ok || foreignValue == nil || !isTableKey(foreignValue) {
+			// Similar to the source datums, foreign datums without a usable key can
+			// never be matched, so don't bother storing them.
+			continue
+		}
+
+		if vals, ok := foreign.GetOK(foreignValue); ok {
+			foreign.Set(foreignValue, append(vals.([]interface{}), foreignDatum))
+		} else {
+			foreign.Set(foreignValue, []interface{}{foreignDatum})
+		}
+	}
+	ls.foreign = foreign
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/utagai/look/datum"
@@ -21,12 +22,14 @@ type MapStream struct {
 }
 
 // Next implements the datum.DatumStream interface.
-func (fs *MapStream) Next() (datum.Datum, error) {
+func (fs *MapStream) Next(ctx context.Context) (datum.Datum, error) {
 	// Keep iterating the stream and performing assignments per datum.
-	datum, err := fs.source.Next()
+	datum, err := fs.source.Next(ctx)
 	if err != nil {
 		return nil, err
 	}
+	// Assign into a copy, so the source data is left untouched.
+	datum = datum.Copy()
 
 	for _, assignment := range fs.Map.Assignments {
 		// If we failed, move onto the next datum.
@@ -0,0 +1,202 @@
+package execution
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+// MatchSpans returns the spans of the JSON of the datum that match the filters
+// of the given stages, e.g. for highlighting them. Only the checks of a field
+// against a literal are taken into account, namely:
+//
+//	.a = <literal>          the whole field matches
+//	.a contains "<literal>" each occurrence of the literal in the field matches
+//	regex(.a, "<pattern>")  each match of the pattern in the field matches
+//
+// The checks are made against the datum as given, rather than the datum as it
+// was when it was filtered, so a field that a later stage changed or removed
+// is not highlighted.
+func MatchSpans(stages []breeze.Stage, d datum.Datum) []datum.Span {
+	fields := d.FieldSpans()
+	spans := []datum.Span{}
+	for _, stage := range stages {
+		filter, ok := stage.(*breeze.Filter)
+		if !ok {
+			continue
+		}
+
+		for _, expr := range filter.Exprs {
+			spans = append(spans, exprMatchSpans(expr, d, fields)...)
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Start < spans[j].Start
+	})
+	return spans
+}
+
+func exprMatchSpans(expr breeze.Expr, d datum.Datum, fields []datum.FieldSpan) []datum.Span {
+	switch e := expr.(type) {
+	case *breeze.BinaryExpr:
+		switch e.Op {
+		case breeze.BinaryOpEquals:
+			fieldRef, ok := fieldRefAgainstScalar(e.Left, e.Right)
+			if !ok {
+				fieldRef, ok = fieldRefAgainstScalar(e.Right, e.Left)
+			}
+			if !ok {
+				return nil
+			}
+
+			field, ok := findFieldSpan(d, fields, fieldRef.Field)
+			if !ok || !exprIsTrue(e, d) {
+				return nil
+			}
+			return []datum.Span{{Start: field.Key.Start, End: field.Value.End}}
+		case breeze.BinaryOpContains:
+			fieldRef, ok := fieldRefAgainstScalar(e.Left, e.Right)
+			if !ok || !exprIsTrue(e, d) {
+				return nil
+			}
+
+			substr := e.Right.(*breeze.Scalar).Stringified
+			return fieldMatchSpans(d, fields, fieldRef.Field, func(s string) [][]int {
+				return findAllIndex(s, substr)
+			})
+		}
+	case *breeze.Function:
+		if e.Name != "regex" || len(e.Args) != 2 {
+			return nil
+		}
+
+		fieldRef, ok := fieldRefAgainstScalar(e.Args[0], e.Args[1])
+		if !ok || !exprIsTrue(e, d) {
+			return nil
+		}
+		pattern, err := regexp.Compile(e.Args[1].(*breeze.Scalar).Stringified)
+		if err != nil {
+			return nil
+		}
+
+		return fieldMatchSpans(d, fields, fieldRef.Field, func(s string) [][]int {
+			return pattern.FindAllStringIndex(s, -1)
+		})
+	}
+
+	return nil
+}
+
+// fieldRefAgainstScalar returns the field reference if the check is between a
+// field and a literal.
+func fieldRefAgainstScalar(field, scalar breeze.Expr) (*breeze.FieldRef, bool) {
+	fieldRef, ok := field.(*breeze.FieldRef)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := scalar.(*breeze.Scalar); !ok {
+		return nil, false
+	}
+
+	return fieldRef, true
+}
+
+func exprIsTrue(expr breeze.Expr, d datum.Datum) bool {
+	val, err := evaluateExpr(expr, d)
+	if err != nil {
+		return false
+	}
+
+	res, ok := val.(bool)
+	return ok && res
+}
+
+// findFieldSpan returns the span of the field at the given path. Like getField,
+// a top-level field named after the entire path takes priority.
+func findFieldSpan(d datum.Datum, fields []datum.FieldSpan, path string) (datum.FieldSpan, bool) {
+	keys := []string{path}
+	if _, ok := d[path]; !ok {
+		keys = strings.Split(path, ".")
+	}
+
+outer:
+	for _, field := range fields {
+		if len(field.Path) != len(keys) {
+			continue
+		}
+		for i := range keys {
+			if field.Path[i] != keys[i] {
+				continue outer
+			}
+		}
+
+		return field, true
+	}
+
+	return datum.FieldSpan{}, false
+}
+
+// fieldMatchSpans returns the spans of the matches that find finds in the
+// string field at the given path.
+func fieldMatchSpans(
+	d datum.Datum,
+	fields []datum.FieldSpan,
+	path string,
+	find func(s string) [][]int,
+) []datum.Span {
+	field, ok := findFieldSpan(d, fields, path)
+	if !ok {
+		return nil
+	}
+	val, _ := getField(d, path)
+	s, ok := val.(string)
+	if !ok {
+		// The check still passed, e.g. on the digits of a number, so the best
+		// we can do is the whole value.
+		return []datum.Span{field.Value}
+	}
+
+	quoted, err := json.Marshal(s)
+	if err != nil || string(quoted) != `"`+s+`"` {
+		// The offsets into the string don't carry over to its escaped JSON.
+		return []datum.Span{field.Value}
+	}
+
+	spans := []datum.Span{}
+	for _, match := range find(s) {
+		if match[0] == match[1] {
+			continue
+		}
+		// Skip the opening quote.
+		spans = append(spans, datum.Span{
+			Start: field.Value.Start + 1 + match[0],
+			End:   field.Value.Start + 1 + match[1],
+		})
+	}
+
+	return spans
+}
+
+// findAllIndex returns the indices of the non-overlapping occurrences of substr
+// in s, like regexp.Regexp.FindAllStringIndex.
+func findAllIndex(s, substr string) [][]int {
+	if substr == "" {
+		return nil
+	}
+
+	indices := [][]int{}
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], substr)
+		if i < 0 {
+			return indices
+		}
+		start := offset + i
+		indices = append(indices, []int{start, start + len(substr)})
+		offset = start + len(substr)
+	}
+}
@@ -0,0 +1,118 @@
+package execution
+
+import (
+	"strings"
+
+	"github.com/utagai/look/datum"
+)
+
+// This module implements field paths, which let stages reach into nested
+// objects. A path is a series of field names delimited by '.', e.g. 'a.b.c'
+// refers to the field 'c' of the object at 'b' of the object at 'a'.
+//
+// For the sake of backwards compatibility, a field whose name is literally the
+// entire path (e.g. a top-level field named 'a.b') always takes priority over
+// the nested interpretation of the path.
+//
+// The functions that modify a datum expect to be given a datum that the caller
+// owns (see datum.Datum.Copy), but never modify the nested objects in it.
+// Instead, they copy each object along the path before changing it, since the
+// nested objects are still shared with the source data.
+
+// getField returns the value at the given path of the datum, and whether it
+// exists. Paths that run into non-objects before reaching their end do not
+// exist.
+func getField(d datum.Datum, path string) (interface{}, bool) {
+	if val, ok := d[path]; ok {
+		return val, true
+	}
+
+	var obj map[string]interface{} = d
+	fields := strings.Split(path, ".")
+	for _, field := range fields[:len(fields)-1] {
+		var ok bool
+		obj, ok = asObject(obj[field])
+		if !ok {
+			return nil, false
+		}
+	}
+
+	val, ok := obj[fields[len(fields)-1]]
+	return val, ok
+}
+
+// setField sets the value at the given path of the datum. Missing intermediate
+// objects along the path are created. If an intermediate field exists but is
+// not an object, the datum is left untouched and this returns false.
+func setField(d datum.Datum, path string, value interface{}) bool {
+	if _, ok := d[path]; ok {
+		d[path] = value
+		return true
+	}
+
+	var obj map[string]interface{} = d
+	fields := strings.Split(path, ".")
+	for _, field := range fields[:len(fields)-1] {
+		next, exists := obj[field]
+		if !exists {
+			next = map[string]interface{}{}
+		}
+
+		child, ok := copyObject(next)
+		if !ok {
+			return false
+		}
+		obj[field] = child
+		obj, _ = asObject(child)
+	}
+
+	obj[fields[len(fields)-1]] = value
+	return true
+}
+
+// unsetField removes the value at the given path of the datum, if it exists.
+func unsetField(d datum.Datum, path string) {
+	if _, ok := d[path]; ok {
+		delete(d, path)
+		return
+	}
+
+	if _, ok := getField(d, path); !ok {
+		// Avoid needlessly copying the objects along the path.
+		return
+	}
+
+	var obj map[string]interface{} = d
+	fields := strings.Split(path, ".")
+	for _, field := range fields[:len(fields)-1] {
+		// This can't fail, since we know the whole path exists.
+		child, _ := copyObject(obj[field])
+		obj[field] = child
+		obj, _ = asObject(child)
+	}
+
+	delete(obj, fields[len(fields)-1])
+}
+
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch obj := v.(type) {
+	case datum.Datum:
+		return obj, true
+	case map[string]interface{}:
+		return obj, true
+	default:
+		return nil, false
+	}
+}
+
+// copyObject returns a shallow copy of the given object, preserving its type.
+func copyObject(v interface{}) (interface{}, bool) {
+	switch obj := v.(type) {
+	case datum.Datum:
+		return obj.Copy(), true
+	case map[string]interface{}:
+		return map[string]interface{}(datum.Datum(obj).Copy()), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,91 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+// StageStats describes the work done by a single stage of an execution.
+type StageStats struct {
+	// Stage is the name of the stage.
+	Stage string
+	// RowsIn is the number of datums the stage read.
+	RowsIn int
+	// RowsOut is the number of datums the stage returned.
+	RowsOut int
+	// Elapsed is the time spent in the stage itself, excluding the time spent in
+	// the stages before it.
+	Elapsed time.Duration
+}
+
+// Profile collects the stats of each stage of an execution. Like the execution
+// itself, the stats are collected lazily, so they are only complete once the
+// stream returned alongside the profile has been exhausted.
+type Profile struct {
+	stages []*profiledStream
+}
+
+// Stages returns the stats of each stage, in the order the stages execute.
+func (p *Profile) Stages() []StageStats {
+	stats := make([]StageStats, len(p.stages))
+	for i, stage := range p.stages {
+		stats[i] = StageStats{
+			Stage:   stage.name,
+			RowsIn:  stage.upstream.rows,
+			RowsOut: stage.rows,
+			// The time spent in a stream includes the time spent waiting on the
+			// streams upstream of it.
+			Elapsed: stage.elapsed - stage.upstream.elapsed,
+		}
+	}
+
+	return stats
+}
+
+// ExecuteProfiled is like Execute, but also instruments each stage, and
+// returns a profile of the execution.
+func ExecuteProfiled(
+	ctx context.Context,
+	stream datum.Stream,
+	stages []breeze.Stage,
+) (datum.Stream, *Profile, error) {
+	profile := &Profile{}
+	upstream := &profiledStream{name: "source", source: stream}
+	for _, stage := range stages {
+		stageStream, err := Execute(ctx, upstream, []breeze.Stage{stage})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		profiled := &profiledStream{name: stage.Name(), source: stageStream, upstream: upstream}
+		profile.stages = append(profile.stages, profiled)
+		upstream = profiled
+	}
+
+	return upstream, profile, nil
+}
+
+// profiledStream counts the datums returned by its source, and the time spent
+// waiting on it.
+type profiledStream struct {
+	name     string
+	source   datum.Stream
+	upstream *profiledStream
+	rows     int
+	elapsed  time.Duration
+}
+
+// Next implements the datum.Stream interface.
+func (ps *profiledStream) Next(ctx context.Context) (datum.Datum, error) {
+	start := time.Now()
+	d, err := ps.source.Next(ctx)
+	ps.elapsed += time.Since(start)
+	if err == nil {
+		ps.rows++
+	}
+
+	return d, err
+}
@@ -0,0 +1,58 @@
+package execution
+
+import (
+	"context"
+	"strings"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeRename(rename *breeze.Rename, stream datum.Stream) *RenameStream {
+	return &RenameStream{
+		Rename: rename,
+		source: stream,
+	}
+}
+
+// RenameStream is an implementation of datum.Stream for the rename stage.
+type RenameStream struct {
+	*breeze.Rename
+	source datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (rs *RenameStream) Next(ctx context.Context) (datum.Datum, error) {
+	datum, err := rs.source.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	datum = datum.Copy()
+
+	for _, rename := range rs.Renames {
+		renameField(datum, rename.From, rename.To)
+	}
+
+	return datum, nil
+}
+
+// renameField moves the value at one path to another. It does nothing if the
+// source field does not exist, or if the destination can't be set (see
+// setField). Moving a field into itself (e.g. a to a.b) is also ignored.
+func renameField(d datum.Datum, from, to string) {
+	if from == to || strings.HasPrefix(to, from+".") {
+		return
+	}
+
+	value, ok := getField(d, from)
+	if !ok {
+		return
+	}
+
+	// Unset the source first, since the destination may be one of its
+	// ancestors (e.g. a.b to a), which setting would replace.
+	unsetField(d, from)
+	if !setField(d, to, value) {
+		setField(d, from, value)
+	}
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeSample(sample *breeze.Sample, stream datum.Stream) datum.Stream {
+	seed := time.Now().UnixNano()
+	if sample.Seed != nil {
+		seed = *sample.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	if sample.Percent > 0 {
+		return &BernoulliSampleStream{
+			Sample: sample,
+			source: stream,
+			rng:    rng,
+		}
+	}
+
+	return &ReservoirSampleStream{
+		Sample: sample,
+		source: stream,
+		rng:    rng,
+	}
+}
+
+// ReservoirSampleStream is an implementation of datum.Stream for fixed-size
+// samples. It uses reservoir sampling, so it only ever holds the sample itself
+// in memory, but must read the entire input before returning anything.
+// The sampled datums are returned in their original relative order.
+type ReservoirSampleStream struct {
+	*breeze.Sample
+	source        datum.Stream
+	rng           *rand.Rand
+	sampledSource datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (rs *ReservoirSampleStream) Next(ctx context.Context) (datum.Datum, error) {
+	if rs.sampledSource == nil {
+		if err := rs.sampleSource(ctx); err != nil {
+			return nil, fmt.Errorf("failed to sample data: %w", err)
+		}
+	}
+
+	return rs.sampledSource.Next(ctx)
+}
+
+func (rs *ReservoirSampleStream) sampleSource(ctx context.Context) error {
+	type indexedDatum struct {
+		datum datum.Datum
+		index int
+	}
+
+	reservoir := make([]indexedDatum, 0, rs.Size)
+	for i := 0; ; i++ {
+		sourceDatum, err := rs.source.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+
+		if len(reservoir) < rs.Size {
+			reservoir = append(reservoir, indexedDatum{datum: sourceDatum, index: i})
+			continue
+		}
+
+		// Algorithm R: the i'th datum replaces a random member of the reservoir
+		// with probability Size/(i+1).
+		if j := rs.rng.Intn(i + 1); j < rs.Size {
+			reservoir[j] = indexedDatum{datum: sourceDatum, index: i}
+		}
+	}
+
+	sort.Slice(reservoir, func(i, j int) bool {
+		return reservoir[i].index < reservoir[j].index
+	})
+
+	datums := make([]datum.Datum, len(reservoir))
+	for i := range reservoir {
+		datums[i] = reservoir[i].datum
+	}
+	rs.sampledSource = datum.NewSliceStream(datums)
+
+	return nil
+}
+
+// BernoulliSampleStream is an implementation of datum.Stream for
+// percentage-based samples. Each datum is independently kept with the given
+// probability, so it streams without buffering anything.
+type BernoulliSampleStream struct {
+	*breeze.Sample
+	source datum.Stream
+	rng    *rand.Rand
+}
+
+// Next implements the datum.DatumStream interface.
+func (bs *BernoulliSampleStream) Next(ctx context.Context) (datum.Datum, error) {
+	for {
+		datum, err := bs.source.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if bs.rng.Float64()*100 < bs.Percent {
+			return datum, nil
+		}
+	}
+}
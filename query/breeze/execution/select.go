@@ -0,0 +1,39 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeSelect(sel *breeze.Select, stream datum.Stream) *SelectStream {
+	return &SelectStream{
+		Select: sel,
+		source: stream,
+	}
+}
+
+// SelectStream is an implementation of datum.Stream for the select stage.
+type SelectStream struct {
+	*breeze.Select
+	source datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (ss *SelectStream) Next(ctx context.Context) (datum.Datum, error) {
+	sourceDatum, err := ss.source.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Since we build a new datum, the source datum is left untouched.
+	selected := datum.Datum{}
+	for _, field := range ss.Fields {
+		if value, ok := getField(sourceDatum, field); ok {
+			setField(selected, field, value)
+		}
+	}
+
+	return selected, nil
+}
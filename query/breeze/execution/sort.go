@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -24,17 +25,17 @@ type SortStream struct {
 }
 
 // Next implements the DatumStream interface.
-func (ss *SortStream) Next() (datum.Datum, error) {
+func (ss *SortStream) Next(ctx context.Context) (datum.Datum, error) {
 	if ss.sortedDatums == nil {
-		if err := ss.sortStream(); err != nil {
+		if err := ss.sortStream(ctx); err != nil {
 			return nil, fmt.Errorf("failed to sort data: %w", err)
 		}
 	}
-	return ss.sortedSource.Next()
+	return ss.sortedSource.Next(ctx)
 }
 
-func (ss *SortStream) sortStream() error {
-	datums, err := datum.StreamToSlice(ss.source)
+func (ss *SortStream) sortStream(ctx context.Context) error {
+	datums, err := datum.StreamToSlice(ctx, ss.source)
 	if err != nil {
 		return fmt.Errorf("failed to read data: %w", err)
 	}
@@ -59,13 +60,13 @@ func (ds sortableDatums) Less(i, j int) bool {
 	ithDoc := ds.datums[i]
 	jthDoc := ds.datums[j]
 
-	ithValue, ok := ithDoc[ds.fieldName]
+	ithValue, ok := getField(ithDoc, ds.fieldName)
 	if !ok {
 		// Treat documents where the field does not exist as being less than.
 		return true
 	}
 
-	jthValue, ok := jthDoc[ds.fieldName]
+	jthValue, ok := getField(jthDoc, ds.fieldName)
 	if !ok {
 		// Ditto above.
 		return true
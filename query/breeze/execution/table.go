@@ -26,6 +26,18 @@ func newTable() *table {
 	}
 }
 
+// isTableKey returns true if the given value can be used as a key in a table.
+func isTableKey(key interface{}) bool {
+	switch key.(type) {
+	case nil, string, bool:
+		return true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t *table) Get(key interface{}) interface{} {
 	val, _ := t.GetOK(key)
 	return val
@@ -0,0 +1,26 @@
+package execution
+
+import (
+	"math"
+	"time"
+)
+
+// convertPotentialTime interprets the given value as a point in time. Numbers
+// are treated as seconds since the Unix epoch, and strings are parsed as
+// RFC 3339 timestamps.
+func convertPotentialTime(a interface{}) (time.Time, bool) {
+	if num, ok := convertPotentialNumber(a); ok {
+		secs, frac := math.Modf(num)
+		return time.Unix(int64(secs), int64(frac*float64(time.Second))).UTC(), true
+	}
+
+	if str, ok := convertPotentialString(a); ok {
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	return time.Time{}, false
+}
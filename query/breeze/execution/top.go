@@ -0,0 +1,167 @@
+package execution
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeTop(top *breeze.Top, stream datum.Stream) *TopStream {
+	return &TopStream{
+		Top:    top,
+		source: stream,
+	}
+}
+
+// TopStream is an implementation of datum.Stream for the top stage.
+// It keeps a bounded heap of K datums for each group, so its memory usage is
+// O(K * groups) rather than O(N), like a sort would be.
+type TopStream struct {
+	*breeze.Top
+	source    datum.Stream
+	topSource datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (ts *TopStream) Next(ctx context.Context) (datum.Datum, error) {
+	if ts.topSource == nil {
+		if err := ts.selectTop(ctx); err != nil {
+			return nil, fmt.Errorf("failed to select top data: %w", err)
+		}
+	}
+
+	return ts.topSource.Next(ctx)
+}
+
+func (ts *TopStream) selectTop(ctx context.Context) error {
+	table := newTable()
+	// Keep track of the order in which groups first appear, so that the output
+	// is deterministic.
+	groupKeys := []interface{}{}
+
+	for i := 0; ; i++ {
+		sourceDatum, err := ts.source.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+
+		value, ok := getField(sourceDatum, ts.Field)
+		if !ok {
+			// If the field doesn't exist, ignore the document.
+			continue
+		}
+
+		var groupKey interface{}
+		if ts.PerField != nil {
+			groupKey, ok = getField(sourceDatum, *ts.PerField)
+			if !ok || !isTableKey(groupKey) {
+				// Similarly, ignore documents that don't belong to a group.
+				continue
+			}
+		}
+
+		h, ok := table.GetOK(groupKey)
+		if !ok {
+			h = &topHeap{descending: ts.Descending}
+			table.Set(groupKey, h)
+			groupKeys = append(groupKeys, groupKey)
+		}
+		h.(*topHeap).offer(rankedDatum{datum: sourceDatum, value: value, index: i}, ts.K)
+	}
+
+	results := []datum.Datum{}
+	for _, groupKey := range groupKeys {
+		results = append(results, table.Get(groupKey).(*topHeap).sorted()...)
+	}
+
+	ts.topSource = datum.NewSliceStream(results)
+
+	return nil
+}
+
+type rankedDatum struct {
+	datum datum.Datum
+	value interface{}
+	// index is the position of the datum in the input, used for breaking ties
+	// in favor of earlier datums.
+	index int
+}
+
+// topHeap is a heap of the best datums seen so far, whose root is the worst of
+// them, so that it is cheap to check against and evict.
+type topHeap struct {
+	items      []rankedDatum
+	descending bool
+}
+
+var _ heap.Interface = (*topHeap)(nil)
+
+func (h *topHeap) better(a, b rankedDatum) bool {
+	switch Compare(a.value, b.value) {
+	case Greater:
+		return h.descending
+	case Lesser:
+		return !h.descending
+	default:
+		return a.index < b.index
+	}
+}
+
+func (h *topHeap) offer(item rankedDatum, k int) {
+	if len(h.items) < k {
+		heap.Push(h, item)
+		return
+	}
+
+	if h.better(item, h.items[0]) {
+		h.items[0] = item
+		heap.Fix(h, 0)
+	}
+}
+
+func (h *topHeap) sorted() []datum.Datum {
+	sort.Slice(h.items, func(i, j int) bool {
+		return h.better(h.items[i], h.items[j])
+	})
+
+	datums := make([]datum.Datum, len(h.items))
+	for i := range h.items {
+		datums[i] = h.items[i].datum
+	}
+
+	return datums
+}
+
+// Len implements the heap.Interface interface.
+func (h *topHeap) Len() int {
+	return len(h.items)
+}
+
+// Less implements the heap.Interface interface.
+func (h *topHeap) Less(i, j int) bool {
+	return h.better(h.items[j], h.items[i])
+}
+
+// Swap implements the heap.Interface interface.
+func (h *topHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+// Push implements the heap.Interface interface.
+func (h *topHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(rankedDatum))
+}
+
+// Pop implements the heap.Interface interface.
+func (h *topHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
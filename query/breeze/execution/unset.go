@@ -0,0 +1,36 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeUnset(unset *breeze.Unset, stream datum.Stream) *UnsetStream {
+	return &UnsetStream{
+		Unset:  unset,
+		source: stream,
+	}
+}
+
+// UnsetStream is an implementation of datum.Stream for the unset stage.
+type UnsetStream struct {
+	*breeze.Unset
+	source datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (us *UnsetStream) Next(ctx context.Context) (datum.Datum, error) {
+	datum, err := us.source.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	datum = datum.Copy()
+
+	for _, field := range us.Fields {
+		unsetField(datum, field)
+	}
+
+	return datum, nil
+}
@@ -0,0 +1,190 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/query/breeze"
+)
+
+func executeWindow(window *breeze.Window, stream datum.Stream) *WindowStream {
+	return &WindowStream{
+		Window: window,
+		source: stream,
+	}
+}
+
+// WindowStream is an implementation of datum.Stream for the window stage.
+// Like sorting, windowing needs to see the entire input before it can return
+// anything, since functions like lead look ahead of the current datum.
+type WindowStream struct {
+	*breeze.Window
+	source         datum.Stream
+	windowedSource datum.Stream
+}
+
+// Next implements the datum.DatumStream interface.
+func (ws *WindowStream) Next(ctx context.Context) (datum.Datum, error) {
+	if ws.windowedSource == nil {
+		if err := ws.windowSource(ctx); err != nil {
+			return nil, fmt.Errorf("failed to window data: %w", err)
+		}
+	}
+
+	return ws.windowedSource.Next(ctx)
+}
+
+func (ws *WindowStream) windowSource(ctx context.Context) error {
+	datums, err := datum.StreamToSlice(ctx, ws.source)
+	if err != nil {
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+
+	// Assign into copies, so the source data is left untouched. The partitions
+	// share the copies, so the values end up in the datums we emit.
+	for i := range datums {
+		datums[i] = datums[i].Copy()
+	}
+
+	for _, partition := range ws.partition(datums) {
+		values := ws.computePartition(partition)
+		for i, d := range partition {
+			setField(d, ws.As, values[i])
+		}
+	}
+
+	ws.windowedSource = datum.NewSliceStream(datums)
+
+	return nil
+}
+
+// partition splits the datums into partitions by the partition field, while
+// preserving their relative order. Datums missing the partition field are put
+// into a partition of their own.
+func (ws *WindowStream) partition(datums []datum.Datum) [][]datum.Datum {
+	if ws.PartitionField == nil {
+		return [][]datum.Datum{datums}
+	}
+
+	table := newTable()
+	keys := []interface{}{}
+	missing := []datum.Datum{}
+	for _, d := range datums {
+		key, ok := getField(d, *ws.PartitionField)
+		if !ok || !isTableKey(key) {
+			missing = append(missing, d)
+			continue
+		}
+
+		if vals, ok := table.GetOK(key); ok {
+			table.Set(key, append(vals.([]datum.Datum), d))
+		} else {
+			table.Set(key, []datum.Datum{d})
+			keys = append(keys, key)
+		}
+	}
+
+	partitions := make([][]datum.Datum, 0, len(keys)+1)
+	for _, key := range keys {
+		partitions = append(partitions, table.Get(key).([]datum.Datum))
+	}
+	if len(missing) > 0 {
+		partitions = append(partitions, missing)
+	}
+
+	return partitions
+}
+
+// computePartition returns the value of the window function for each datum of
+// the partition.
+func (ws *WindowStream) computePartition(partition []datum.Datum) []interface{} {
+	values := make([]interface{}, len(partition))
+	switch ws.Func {
+	case breeze.WindowFuncRowNumber:
+		for i := range partition {
+			values[i] = i + 1
+		}
+	case breeze.WindowFuncLag:
+		for i := range partition {
+			if i-ws.Offset >= 0 {
+				values[i], _ = getField(partition[i-ws.Offset], ws.Field)
+			}
+		}
+	case breeze.WindowFuncLead:
+		for i := range partition {
+			if i+ws.Offset < len(partition) {
+				values[i], _ = getField(partition[i+ws.Offset], ws.Field)
+			}
+		}
+	default:
+		ws.computeAggregates(partition, values)
+	}
+
+	return values
+}
+
+func (ws *WindowStream) computeAggregates(partition []datum.Datum, values []interface{}) {
+	aggFunc := breeze.AggregateFunc(ws.Func)
+
+	if ws.Frame == nil {
+		// Without a frame, this is a running aggregate, so we can just keep
+		// ingesting into the same aggregator.
+		agg := newAggregator(aggFunc)
+		for i, d := range partition {
+			if fieldValue, ok := getField(d, ws.Field); ok {
+				agg.ingest(fieldValue)
+			}
+			values[i] = agg.aggregate()
+		}
+		return
+	}
+
+	for i := range partition {
+		start, ok := ws.frameStart(partition, i)
+		if !ok {
+			// The datum isn't placeable in the frame (e.g. it has no time), so it
+			// has no window to aggregate over.
+			continue
+		}
+
+		agg := newAggregator(aggFunc)
+		for _, d := range partition[start : i+1] {
+			if fieldValue, ok := getField(d, ws.Field); ok {
+				agg.ingest(fieldValue)
+			}
+		}
+		values[i] = agg.aggregate()
+	}
+}
+
+// frameStart returns the index of the first datum in the frame of the i'th
+// datum. For time ranges, this expects the partition to already be sorted on
+// the range field.
+func (ws *WindowStream) frameStart(partition []datum.Datum, i int) (int, bool) {
+	if ws.Frame.Rows > 0 {
+		start := i - ws.Frame.Rows + 1
+		if start < 0 {
+			start = 0
+		}
+		return start, true
+	}
+
+	endValue, _ := getField(partition[i], ws.Frame.RangeField)
+	end, ok := convertPotentialTime(endValue)
+	if !ok {
+		return 0, false
+	}
+
+	start := i
+	for start > 0 {
+		value, _ := getField(partition[start-1], ws.Frame.RangeField)
+		t, ok := convertPotentialTime(value)
+		if !ok || !t.After(end.Add(-ws.Frame.Range)) {
+			break
+		}
+		start--
+	}
+
+	return start, true
+}
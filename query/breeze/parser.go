@@ -4,13 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Parser parses breeze queries.
 type Parser struct {
 	input     string
 	tokenizer Tokenizer
+	// expected collects what the parser expects at the end of the query, if
+	// set. See Expect.
+	expected *Expectation
 }
 
 // NewParser creates a Parser.
@@ -94,6 +99,7 @@ func (p *Parser) parse() ([]Stage, error) {
 		}
 		stages = append(stages, stage)
 		// Now chomp the stage separator and repeat.
+		p.expect(Expectation{Separator: true})
 		token := p.tokenizer.Next()
 		if token == TokenEOF {
 			break // No more stages to parse.
@@ -106,6 +112,7 @@ func (p *Parser) parse() ([]Stage, error) {
 }
 
 func (p *Parser) parseStage() (Stage, error) {
+	p.expect(Expectation{Stages: true})
 	stageToken := p.tokenizer.Next()
 	if stageToken == TokenEOF {
 		return nil, io.EOF
@@ -120,6 +127,22 @@ func (p *Parser) parseStage() (Stage, error) {
 		return p.parseGroup()
 	case TokenMap:
 		return p.parseMap()
+	case TokenLookup:
+		return p.parseLookup()
+	case TokenWindow:
+		return p.parseWindow()
+	case TokenBucket:
+		return p.parseBucket()
+	case TokenTop:
+		return p.parseTop()
+	case TokenSample:
+		return p.parseSample()
+	case TokenRename:
+		return p.parseRename()
+	case TokenUnset:
+		return p.parseUnset()
+	case TokenSelect:
+		return p.parseSelect()
 	default:
 		return nil, fmt.Errorf("unrecognized stage: %q", p.tokenizer.Text())
 	}
@@ -128,6 +151,7 @@ func (p *Parser) parseStage() (Stage, error) {
 func (p *Parser) parseFilter() (*Filter, error) {
 	exprs := []Expr{}
 	for {
+		p.expect(Expectation{Values: true})
 		token, _ := p.tokenizer.Peek()
 		if token == TokenStageSeparator || token == TokenEOF {
 			break // No more checks to parse.
@@ -163,6 +187,102 @@ func (p *Parser) parseSort() (*Sort, error) {
 	}, nil
 }
 
+func (p *Parser) parseTop() (*Top, error) {
+	k, err := p.parsePositiveInt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the number of datums to keep: %w", err)
+	}
+
+	if err := p.parseKeyword("by"); err != nil {
+		return nil, err
+	}
+
+	field, err := p.parseField()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field: %w", err)
+	}
+
+	// Unlike sort, top defaults to descending, since the most common use is
+	// finding the largest values.
+	descending := true
+	p.expect(Expectation{Keywords: []string{"asc", "desc"}})
+	if maybeSortOrder, sortOrderText := p.tokenizer.Peek(); maybeSortOrder == TokenIdent && sortOrderText == "asc" {
+		p.tokenizer.Next()
+		descending = false
+	} else if maybeSortOrder == TokenIdent && sortOrderText == "desc" {
+		p.tokenizer.Next()
+	}
+
+	var perFieldPtr *string
+	p.expect(Expectation{Keywords: []string{"per"}})
+	if maybePer, perText := p.tokenizer.Peek(); maybePer == TokenIdent && perText == "per" {
+		p.tokenizer.Next()
+		perField, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the per field: %w", err)
+		}
+
+		perFieldPtr = &perField
+	}
+
+	return &Top{
+		K:          k,
+		Field:      field,
+		Descending: descending,
+		PerField:   perFieldPtr,
+	}, nil
+}
+
+func (p *Parser) parseSample() (*Sample, error) {
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return nil, errors.New("expected a sample size or percentage, but reached end of query")
+	}
+
+	amount := p.tokenizer.Text()
+	if token != TokenInt && token != TokenFloat {
+		return nil, fmt.Errorf("expected a sample size or percentage, but got %q", amount)
+	}
+
+	sample := &Sample{}
+	if _, maybePercent := p.tokenizer.Peek(); maybePercent == "%" {
+		p.tokenizer.Next()
+		percent, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentage %q: %w", amount, err)
+		}
+
+		if percent <= 0 || percent > 100 {
+			return nil, fmt.Errorf("expected a percentage in (0, 100], but got %v", percent)
+		}
+
+		sample.Percent = percent
+	} else {
+		size, err := strconv.Atoi(amount)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("expected a positive integer sample size, but got %q", amount)
+		}
+
+		sample.Size = size
+	}
+
+	if maybeSeed, seedText := p.tokenizer.Peek(); maybeSeed == TokenIdent && seedText == "seed" {
+		p.tokenizer.Next()
+		if p.tokenizer.Next() != TokenInt {
+			return nil, fmt.Errorf("expected an integer seed, but got %q", p.tokenizer.Text())
+		}
+
+		seed, err := strconv.ParseInt(p.tokenizer.Text(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q: %w", p.tokenizer.Text(), err)
+		}
+
+		sample.Seed = &seed
+	}
+
+	return sample, nil
+}
+
 func (p *Parser) parseGroup() (*Group, error) {
 	var groupByFieldPtr *string
 	if p.parseBy() {
@@ -191,9 +311,163 @@ func (p *Parser) parseGroup() (*Group, error) {
 	}, nil
 }
 
+func (p *Parser) parseWindow() (*Window, error) {
+	var partitionFieldPtr *string
+	if p.parseBy() {
+		partitionField, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the partition field: %w", err)
+		}
+
+		partitionFieldPtr = &partitionField
+	}
+
+	windowFunc, err := p.parseWindowFunc()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse window function: %w", err)
+	}
+
+	window := &Window{
+		Func:           windowFunc,
+		PartitionField: partitionFieldPtr,
+	}
+
+	defaultAs := string(windowFunc)
+	if windowFunc != WindowFuncRowNumber {
+		window.Field, err = p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+		defaultAs = fmt.Sprintf("%s_%s", windowFunc, window.Field)
+	}
+
+	switch windowFunc {
+	case WindowFuncRowNumber:
+	case WindowFuncLag, WindowFuncLead:
+		window.Offset = 1
+		if maybeOffset, _ := p.tokenizer.Peek(); maybeOffset == TokenInt {
+			window.Offset, err = p.parsePositiveInt()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the offset: %w", err)
+			}
+		}
+	default:
+		window.Frame, err = p.parseWindowFrame()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the window frame: %w", err)
+		}
+	}
+
+	window.As, err = p.parseAs(defaultAs)
+	if err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
+
+func (p *Parser) parseBucket() (*Bucket, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field: %w", err)
+	}
+
+	bucket := &Bucket{Field: field}
+
+	p.expect(Expectation{Keywords: []string{"by", "into"}})
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return nil, errors.New("expected 'by' or 'into', but reached end of query")
+	}
+	switch p.tokenizer.Text() {
+	case "by":
+		width, unit, err := p.parseBucketWidth()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the bucket width: %w", err)
+		}
+
+		if unit == "" {
+			bucket.Width, err = strconv.ParseFloat(width, 64)
+		} else {
+			bucket.Duration, err = toDuration(width, unit)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the bucket width: %w", err)
+		}
+		if bucket.Width < 0 || bucket.Duration < 0 || (bucket.Width == 0 && bucket.Duration == 0) {
+			return nil, fmt.Errorf("failed to parse the bucket width: expected a positive number or duration, but got %q", width+unit)
+		}
+	case "into":
+		bucket.NumBuckets, err = p.parsePositiveInt()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the number of buckets: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("expected 'by' or 'into', but got %q", p.tokenizer.Text())
+	}
+
+	for {
+		p.expect(Expectation{AggFuncs: true})
+		token, _ := p.tokenizer.Peek()
+		if token == TokenStageSeparator || token == TokenEOF {
+			break // No more aggregates to parse.
+		} else if token == TokenComma {
+			p.tokenizer.Next()
+			continue
+		}
+
+		aggFunc, err := p.parseAggFunc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse aggregate function: %w", err)
+		}
+
+		aggregateField, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+
+		bucket.Aggregates = append(bucket.Aggregates, BucketAggregate{
+			AggFunc: *aggFunc,
+			Field:   aggregateField,
+			As:      fmt.Sprintf("%s_%s", *aggFunc, aggregateField),
+		})
+	}
+
+	return bucket, nil
+}
+
+// parseBucketWidth parses the width of a bucket, which is either a plain
+// number, or a duration. The unit of the duration is returned separately, and
+// is empty if the width is a plain number.
+func (p *Parser) parseBucketWidth() (string, string, error) {
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return "", "", errors.New("expected a number or duration, but reached end of query")
+	}
+
+	width := p.tokenizer.Text()
+	if token == TokenMinus {
+		return "", "", errors.New("expected a positive number or duration, but got a negative width")
+	}
+	if token != TokenInt && token != TokenFloat {
+		return "", "", fmt.Errorf("expected a number or duration, but got %q", width)
+	}
+
+	// A plain number may be followed by the aggregates, so only treat the
+	// following identifier as a unit if it isn't an aggregate function.
+	unitToken, unit := p.tokenizer.Peek()
+	if _, isAggFunc := aggFuncFromText(unit); unitToken != TokenIdent || isAggFunc {
+		return width, "", nil
+	}
+	p.tokenizer.Next()
+
+	return width, unit, nil
+}
+
 func (p *Parser) parseMap() (*Map, error) {
 	assignments := []FieldAssignment{}
 	for {
+		p.expect(Expectation{Fields: true})
 		token, _ := p.tokenizer.Peek()
 		if token == TokenStageSeparator || token == TokenEOF {
 			break // No more checks to parse.
@@ -211,7 +485,178 @@ func (p *Parser) parseMap() (*Map, error) {
 	return &Map{Assignments: assignments}, nil
 }
 
+func (p *Parser) parseLookup() (*Lookup, error) {
+	mode := p.parseLookupMode()
+
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return nil, errors.New("expected a lookup source, but reached end of query")
+	}
+	source := p.tokenizer.Text()
+	if token != TokenString || !isQuotedString(source) {
+		return nil, fmt.Errorf("expected a quoted lookup source, but got %q", source)
+	}
+
+	if err := p.parseKeyword("on"); err != nil {
+		return nil, err
+	}
+
+	p.expect(Expectation{Fields: true})
+	localField, err := p.parseFieldRef(p.tokenizer.Next())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the local field: %w", err)
+	}
+
+	if err := p.parseEquals(p.tokenizer.Next()); err != nil {
+		return nil, err
+	}
+
+	p.expect(Expectation{Fields: true})
+	foreignField, err := p.parseFieldRef(p.tokenizer.Next())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the foreign field: %w", err)
+	}
+
+	if err := p.parseKeyword("as"); err != nil {
+		return nil, err
+	}
+
+	as, err := p.parseField()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field: %w", err)
+	}
+
+	return &Lookup{
+		Mode:         mode,
+		Source:       source[1 : len(source)-1],
+		LocalField:   localField.Field,
+		ForeignField: foreignField.Field,
+		As:           as,
+	}, nil
+}
+
+func (p *Parser) parseLookupMode() LookupMode {
+	p.expect(Expectation{Keywords: []string{"inner", "left", "anti"}})
+	maybeMode, modeText := p.tokenizer.Peek()
+	if maybeMode == TokenIdent {
+		switch modeText {
+		case "inner":
+			p.tokenizer.Next()
+			return LookupModeInner
+		case "left":
+			p.tokenizer.Next()
+			return LookupModeLeft
+		case "anti":
+			p.tokenizer.Next()
+			return LookupModeAnti
+		}
+	}
+
+	// If it isn't a lookup mode keyword, then take the default behavior of an
+	// inner join.
+	return LookupModeInner
+}
+
+// parseKeyword consumes the next token, expecting it to be the given keyword.
+func (p *Parser) parseKeyword(keyword string) error {
+	p.expect(Expectation{Keywords: []string{keyword}})
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return fmt.Errorf("expected %q, but reached end of query", keyword)
+	}
+
+	if token != TokenIdent || p.tokenizer.Text() != keyword {
+		return fmt.Errorf("expected %q, but got %q", keyword, p.tokenizer.Text())
+	}
+
+	return nil
+}
+
+func (p *Parser) parseRename() (*Rename, error) {
+	renames := []FieldRename{}
+	for {
+		p.expect(Expectation{Fields: true})
+		token, _ := p.tokenizer.Peek()
+		if token == TokenStageSeparator || token == TokenEOF {
+			break // No more renames to parse.
+		} else if token == TokenComma {
+			p.tokenizer.Next()
+			continue
+		}
+
+		from, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+
+		if err := p.parseKeyword("as"); err != nil {
+			return nil, err
+		}
+
+		to, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+
+		renames = append(renames, FieldRename{From: from, To: to})
+	}
+
+	if len(renames) == 0 {
+		return nil, errors.New("expected at least one field to rename")
+	}
+
+	return &Rename{Renames: renames}, nil
+}
+
+func (p *Parser) parseUnset() (*Unset, error) {
+	fields, err := p.parseFieldList("unset")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Unset{Fields: fields}, nil
+}
+
+func (p *Parser) parseSelect() (*Select, error) {
+	fields, err := p.parseFieldList("select")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Select{Fields: fields}, nil
+}
+
+// parseFieldList parses a comma-separated list of fields running to the end of
+// the stage. The purpose of the list is only used for error messages.
+func (p *Parser) parseFieldList(purpose string) ([]string, error) {
+	fields := []string{}
+	for {
+		p.expect(Expectation{Fields: true})
+		token, _ := p.tokenizer.Peek()
+		if token == TokenStageSeparator || token == TokenEOF {
+			break // No more fields to parse.
+		} else if token == TokenComma {
+			p.tokenizer.Next()
+			continue
+		}
+
+		field, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("expected at least one field to %s", purpose)
+	}
+
+	return fields, nil
+}
+
 func (p *Parser) parseBy() bool {
+	p.expect(Expectation{Keywords: []string{"by"}})
 	_, tokStr := p.tokenizer.Peek()
 
 	if tokStr == "by" {
@@ -249,13 +694,17 @@ func (p *Parser) parseAssignment() (*FieldAssignment, error) {
 }
 
 func (p *Parser) parseField() (string, error) {
+	p.expect(Expectation{Fields: true})
 	token := p.tokenizer.Next()
 	if token == TokenEOF {
 		return "", errors.New("expected a field, but reached end of query")
 	}
 
-	if token == TokenIdent || token == TokenString {
-		return p.tokenizer.Text(), nil
+	// Stage names are only keywords where a stage is expected, so a field may be
+	// called e.g. top or sample.
+	if token == TokenIdent || token == TokenString || token.IsStage() {
+		// Fields may optionally be written like field references, e.g. .foo.
+		return strings.TrimPrefix(p.tokenizer.Text(), "."), nil
 	}
 
 	return "", fmt.Errorf("expected a field identifier, but got %q (%s)", p.tokenizer.Text(), token.String())
@@ -343,6 +792,7 @@ func (p *Parser) parseExpr(token Token) (Expr, error) {
 		}
 	}
 
+	p.expect(Expectation{Operators: true})
 	token, _ = p.tokenizer.Peek()
 	bOp, err := p.parseBinaryOp(token)
 	if err != nil {
@@ -400,6 +850,7 @@ func (p *Parser) applyPrecedence(leftExpr Expr, leftOp BinaryOp, rightExpr Expr,
 }
 
 func (p *Parser) parseValue(token Token) (Value, error) {
+	p.expectAt(token, Expectation{Values: true})
 	if token == TokenEOF {
 		return nil, errors.New("expected a value, but reached end of query")
 	}
@@ -601,6 +1052,7 @@ func (p *Parser) parseFunction(token Token) (*Function, error) {
 }
 
 func (p *Parser) parseSortOrder() bool {
+	p.expect(Expectation{Keywords: []string{"asc", "desc"}})
 	maybeSortOrder, sortOrderText := p.tokenizer.Peek()
 	if maybeSortOrder == TokenIdent {
 		switch sortOrderText {
@@ -619,26 +1071,12 @@ func (p *Parser) parseSortOrder() bool {
 }
 
 func (p *Parser) parseAggFunc() (*AggregateFunc, error) {
+	p.expect(Expectation{AggFuncs: true})
 	tok := p.tokenizer.Next()
 	aggFuncText := p.tokenizer.Text()
 	if tok == TokenIdent {
-		var aggFunc AggregateFunc
-		switch aggFuncText {
-		case "sum":
-			aggFunc = AggFuncSum
-		case "avg":
-			aggFunc = AggFuncAvg
-		case "count":
-			aggFunc = AggFuncCount
-		case "min":
-			aggFunc = AggFuncMin
-		case "max":
-			aggFunc = AggFuncMax
-		case "mode":
-			aggFunc = AggFuncMode
-		case "stddev":
-			aggFunc = AggFuncStdDev
-		default:
+		aggFunc, ok := aggFuncFromText(aggFuncText)
+		if !ok {
 			return nil, fmt.Errorf("unrecognized aggregate function: %q", aggFuncText)
 		}
 
@@ -647,3 +1085,168 @@ func (p *Parser) parseAggFunc() (*AggregateFunc, error) {
 
 	return nil, fmt.Errorf("expected an aggregate func, but found %q", aggFuncText)
 }
+
+func aggFuncFromText(aggFuncText string) (AggregateFunc, bool) {
+	switch aggFuncText {
+	case "sum":
+		return AggFuncSum, true
+	case "avg":
+		return AggFuncAvg, true
+	case "count":
+		return AggFuncCount, true
+	case "min":
+		return AggFuncMin, true
+	case "max":
+		return AggFuncMax, true
+	case "mode":
+		return AggFuncMode, true
+	case "stddev":
+		return AggFuncStdDev, true
+	default:
+		return "", false
+	}
+}
+
+func (p *Parser) parseWindowFunc() (WindowFunc, error) {
+	p.expect(Expectation{WindowFuncs: true})
+	tok := p.tokenizer.Next()
+	windowFuncText := p.tokenizer.Text()
+	if tok == TokenEOF {
+		return "", errors.New("expected a window function, but reached end of query")
+	}
+
+	if tok == TokenIdent {
+		switch windowFuncText {
+		case "row_number":
+			return WindowFuncRowNumber, nil
+		case "lag":
+			return WindowFuncLag, nil
+		case "lead":
+			return WindowFuncLead, nil
+		}
+
+		if aggFunc, ok := aggFuncFromText(windowFuncText); ok {
+			return WindowFunc(aggFunc), nil
+		}
+
+		return "", fmt.Errorf("unrecognized window function: %q", windowFuncText)
+	}
+
+	return "", fmt.Errorf("expected a window function, but found %q", windowFuncText)
+}
+
+func (p *Parser) parseWindowFrame() (*WindowFrame, error) {
+	p.expect(Expectation{Keywords: []string{"rows", "range"}})
+	_, frameText := p.tokenizer.Peek()
+	switch frameText {
+	case "rows":
+		p.tokenizer.Next()
+		rows, err := p.parsePositiveInt()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the number of rows: %w", err)
+		}
+
+		return &WindowFrame{Rows: rows}, nil
+	case "range":
+		p.tokenizer.Next()
+		rng, err := p.parseDuration()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the range: %w", err)
+		}
+
+		if err := p.parseKeyword("on"); err != nil {
+			return nil, err
+		}
+
+		rangeField, err := p.parseField()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the range field: %w", err)
+		}
+
+		return &WindowFrame{Range: rng, RangeField: rangeField}, nil
+	}
+
+	// No frame means the window is every preceding datum.
+	return nil, nil
+}
+
+// parseAs parses an optional trailing 'as <field>', returning the field, or
+// the given default if there isn't one.
+func (p *Parser) parseAs(defaultField string) (string, error) {
+	p.expect(Expectation{Keywords: []string{"as"}})
+	maybeAs, asText := p.tokenizer.Peek()
+	if maybeAs != TokenIdent || asText != "as" {
+		return defaultField, nil
+	}
+	p.tokenizer.Next()
+
+	field, err := p.parseField()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse field: %w", err)
+	}
+
+	return field, nil
+}
+
+func (p *Parser) parsePositiveInt() (int, error) {
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return 0, errors.New("expected an integer, but reached end of query")
+	}
+
+	text := p.tokenizer.Text()
+	if token != TokenInt {
+		return 0, fmt.Errorf("expected an integer, but got %q", text)
+	}
+
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q: %w", text, err)
+	}
+
+	if n <= 0 {
+		return 0, fmt.Errorf("expected a positive integer, but got %d", n)
+	}
+
+	return n, nil
+}
+
+// parseDuration parses a duration, like 30s or 1.5h. On top of the units
+// understood by time.ParseDuration, this accepts 'd' for days.
+func (p *Parser) parseDuration() (time.Duration, error) {
+	token := p.tokenizer.Next()
+	if token == TokenEOF {
+		return 0, errors.New("expected a duration, but reached end of query")
+	}
+
+	amount := p.tokenizer.Text()
+	if token != TokenInt && token != TokenFloat {
+		return 0, fmt.Errorf("expected a duration, but got %q", amount)
+	}
+
+	unitToken, unit := p.tokenizer.Peek()
+	if unitToken != TokenIdent {
+		return 0, fmt.Errorf("expected a unit for the duration %q", amount)
+	}
+	p.tokenizer.Next()
+
+	return toDuration(amount, unit)
+}
+
+func toDuration(amount, unit string) (time.Duration, error) {
+	if unit == "d" {
+		days, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", amount+unit, err)
+		}
+
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+
+	duration, err := time.ParseDuration(amount + unit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", amount+unit, err)
+	}
+
+	return duration, nil
+}
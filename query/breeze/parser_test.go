@@ -2,6 +2,7 @@ package breeze_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -850,6 +851,392 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			query: `lookup "users.json" on .user_id = .id as user`,
+			stages: []breeze.Stage{
+				&breeze.Lookup{
+					Mode:         breeze.LookupModeInner,
+					Source:       "users.json",
+					LocalField:   "user_id",
+					ForeignField: "id",
+					As:           "user",
+				},
+			},
+		},
+		{
+			query: `lookup left "users.json" on .user_id = .id as user | filter .foo`,
+			stages: []breeze.Stage{
+				&breeze.Lookup{
+					Mode:         breeze.LookupModeLeft,
+					Source:       "users.json",
+					LocalField:   "user_id",
+					ForeignField: "id",
+					As:           "user",
+				},
+				&breeze.Filter{
+					Exprs: []breeze.Expr{
+						&breeze.FieldRef{
+							Field: "foo",
+						},
+					},
+				},
+			},
+		},
+		{
+			query: `lookup anti "users.json" on .user_id = .id as user`,
+			stages: []breeze.Stage{
+				&breeze.Lookup{
+					Mode:         breeze.LookupModeAnti,
+					Source:       "users.json",
+					LocalField:   "user_id",
+					ForeignField: "id",
+					As:           "user",
+				},
+			},
+		},
+		{
+			query:  `lookup users.json on .user_id = .id as user`,
+			errMsg: "failed to parse: expected a quoted lookup source, but got \"users.json\"",
+		},
+		{
+			query:  `lookup "users.json" .user_id = .id as user`,
+			errMsg: "failed to parse: expected \"on\", but got \".user_id\"",
+		},
+		{
+			query:  `lookup "users.json" on .user_id = .id`,
+			errMsg: "failed to parse: expected \"as\", but reached end of query",
+		},
+		{
+			query: "window sum a",
+			stages: []breeze.Stage{
+				&breeze.Window{
+					Func:  breeze.WindowFunc(breeze.AggFuncSum),
+					Field: "a",
+					As:    "sum_a",
+				},
+			},
+		},
+		{
+			query: "window by service avg latency rows 3 as rolling",
+			stages: []breeze.Stage{
+				&breeze.Window{
+					Func:           breeze.WindowFunc(breeze.AggFuncAvg),
+					Field:          "latency",
+					PartitionField: strPtr("service"),
+					Frame:          &breeze.WindowFrame{Rows: 3},
+					As:             "rolling",
+				},
+			},
+		},
+		{
+			query: "window max latency range 1m30s on time",
+			stages: []breeze.Stage{
+				&breeze.Window{
+					Func:  breeze.WindowFunc(breeze.AggFuncMax),
+					Field: "latency",
+					Frame: &breeze.WindowFrame{Range: 90 * time.Second, RangeField: "time"},
+					As:    "max_latency",
+				},
+			},
+		},
+		{
+			query: "window count a range 2d on time",
+			stages: []breeze.Stage{
+				&breeze.Window{
+					Func:  breeze.WindowFunc(breeze.AggFuncCount),
+					Field: "a",
+					Frame: &breeze.WindowFrame{Range: 48 * time.Hour, RangeField: "time"},
+					As:    "count_a",
+				},
+			},
+		},
+		{
+			query: "window row_number | window lag a | window lead a 2 as next",
+			stages: []breeze.Stage{
+				&breeze.Window{
+					Func: breeze.WindowFuncRowNumber,
+					As:   "row_number",
+				},
+				&breeze.Window{
+					Func:   breeze.WindowFuncLag,
+					Field:  "a",
+					Offset: 1,
+					As:     "lag_a",
+				},
+				&breeze.Window{
+					Func:   breeze.WindowFuncLead,
+					Field:  "a",
+					Offset: 2,
+					As:     "next",
+				},
+			},
+		},
+		{
+			query:  "window median a",
+			errMsg: "failed to parse: failed to parse window function: unrecognized window function: \"median\"",
+		},
+		{
+			query:  "window sum a rows 0",
+			errMsg: "failed to parse: failed to parse the window frame: failed to parse the number of rows: expected a positive integer, but got 0",
+		},
+		{
+			query:  "window sum a range 5 on time",
+			errMsg: "failed to parse: failed to parse the window frame: failed to parse the range: invalid duration \"5on\": time: unknown unit \"on\" in duration \"5on\"",
+		},
+		{
+			query: "bucket latency by 50",
+			stages: []breeze.Stage{
+				&breeze.Bucket{
+					Field: "latency",
+					Width: 50,
+				},
+			},
+		},
+		{
+			query: "bucket time by 1m avg latency, max latency",
+			stages: []breeze.Stage{
+				&breeze.Bucket{
+					Field:    "time",
+					Duration: time.Minute,
+					Aggregates: []breeze.BucketAggregate{
+						{AggFunc: breeze.AggFuncAvg, Field: "latency", As: "avg_latency"},
+						{AggFunc: breeze.AggFuncMax, Field: "latency", As: "max_latency"},
+					},
+				},
+			},
+		},
+		{
+			query: "bucket latency by 0.5 count latency",
+			stages: []breeze.Stage{
+				&breeze.Bucket{
+					Field: "latency",
+					Width: 0.5,
+					Aggregates: []breeze.BucketAggregate{
+						{AggFunc: breeze.AggFuncCount, Field: "latency", As: "count_latency"},
+					},
+				},
+			},
+		},
+		{
+			query: "bucket latency into 10",
+			stages: []breeze.Stage{
+				&breeze.Bucket{
+					Field:      "latency",
+					NumBuckets: 10,
+				},
+			},
+		},
+		{
+			query:  "bucket latency",
+			errMsg: "failed to parse: expected 'by' or 'into', but reached end of query",
+		},
+		{
+			query:  "bucket latency by fifty",
+			errMsg: "failed to parse: failed to parse the bucket width: expected a number or duration, but got \"fifty\"",
+		},
+		{
+			query:  "bucket latency by 0",
+			errMsg: "failed to parse: failed to parse the bucket width: expected a positive number or duration, but got \"0\"",
+		},
+		{
+			query:  "bucket time by 0s",
+			errMsg: "failed to parse: failed to parse the bucket width: expected a positive number or duration, but got \"0s\"",
+		},
+		{
+			query:  "bucket latency by -5",
+			errMsg: "failed to parse: failed to parse the bucket width: expected a positive number or duration, but got a negative width",
+		},
+		{
+			query: "top 10 by latency",
+			stages: []breeze.Stage{
+				&breeze.Top{
+					K:          10,
+					Field:      "latency",
+					Descending: true,
+				},
+			},
+		},
+		{
+			query: "top 3 by latency asc per service",
+			stages: []breeze.Stage{
+				&breeze.Top{
+					K:          3,
+					Field:      "latency",
+					Descending: false,
+					PerField:   strPtr("service"),
+				},
+			},
+		},
+		{
+			query:  "top by latency",
+			errMsg: "failed to parse: failed to parse the number of datums to keep: expected an integer, but got \"by\"",
+		},
+		{
+			query:  "top 10 latency",
+			errMsg: "failed to parse: expected \"by\", but got \"latency\"",
+		},
+		{
+			query: "filter .top > 3 | sort top desc | top 1 by sample per select",
+			stages: []breeze.Stage{
+				&breeze.Filter{
+					Exprs: []breeze.Expr{
+						&breeze.BinaryExpr{
+							Left: &breeze.FieldRef{
+								Field: "top",
+							},
+							Right: &breeze.Scalar{
+								Kind:        breeze.ScalarKindNumber,
+								Stringified: "3",
+							},
+							Op: breeze.BinaryOpGeq,
+						},
+					},
+				},
+				&breeze.Sort{
+					Field:      "top",
+					Descending: true,
+				},
+				&breeze.Top{
+					K:          1,
+					Field:      "sample",
+					Descending: true,
+					PerField:   strPtr("select"),
+				},
+			},
+		},
+		{
+			query: "select .lookup, window, bucket.rename",
+			stages: []breeze.Stage{
+				&breeze.Select{
+					Fields: []string{"lookup", "window", "bucket.rename"},
+				},
+			},
+		},
+		{
+			query:  "top",
+			errMsg: "failed to parse: failed to parse the number of datums to keep: expected an integer, but reached end of query",
+		},
+		{
+			query: "sample 1000",
+			stages: []breeze.Stage{
+				&breeze.Sample{
+					Size: 1000,
+				},
+			},
+		},
+		{
+			query: "sample 1% seed 42",
+			stages: []breeze.Stage{
+				&breeze.Sample{
+					Percent: 1,
+					Seed:    int64Ptr(42),
+				},
+			},
+		},
+		{
+			query: "sample 0.5 % | sort foo",
+			stages: []breeze.Stage{
+				&breeze.Sample{
+					Percent: 0.5,
+				},
+				&breeze.Sort{
+					Field: "foo",
+				},
+			},
+		},
+		{
+			query:  "sample 2.5",
+			errMsg: "failed to parse: expected a positive integer sample size, but got \"2.5\"",
+		},
+		{
+			query:  "sample 200%",
+			errMsg: "failed to parse: expected a percentage in (0, 100], but got 200",
+		},
+		{
+			query: "map .a.b = 1",
+			stages: []breeze.Stage{
+				&breeze.Map{
+					Assignments: []breeze.FieldAssignment{
+						{
+							Field: "a.b",
+							Assignment: &breeze.Scalar{
+								Kind:        breeze.ScalarKindNumber,
+								Stringified: "1",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "sort .a.b desc | filter .a.b = 1",
+			stages: []breeze.Stage{
+				&breeze.Sort{
+					Field:      "a.b",
+					Descending: true,
+				},
+				&breeze.Filter{
+					Exprs: []breeze.Expr{
+						&breeze.BinaryExpr{
+							Left: &breeze.FieldRef{
+								Field: "a.b",
+							},
+							Right: &breeze.Scalar{
+								Kind:        breeze.ScalarKindNumber,
+								Stringified: "1",
+							},
+							Op: breeze.BinaryOpEquals,
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "rename .old as .new, a.b as c",
+			stages: []breeze.Stage{
+				&breeze.Rename{
+					Renames: []breeze.FieldRename{
+						{From: "old", To: "new"},
+						{From: "a.b", To: "c"},
+					},
+				},
+			},
+		},
+		{
+			query: "unset .tmp, a.b | unset c",
+			stages: []breeze.Stage{
+				&breeze.Unset{
+					Fields: []string{"tmp", "a.b"},
+				},
+				&breeze.Unset{
+					Fields: []string{"c"},
+				},
+			},
+		},
+		{
+			query: "select .b, .a.c",
+			stages: []breeze.Stage{
+				&breeze.Select{
+					Fields: []string{"b", "a.c"},
+				},
+			},
+		},
+		{
+			query:  "select",
+			errMsg: "failed to parse: expected at least one field to select",
+		},
+		{
+			query:  "rename .old .new",
+			errMsg: "failed to parse: expected \"as\", but got \".new\"",
+		},
+		{
+			query:  "rename",
+			errMsg: "failed to parse: expected at least one field to rename",
+		},
+		{
+			query:  "unset | sort a",
+			errMsg: "failed to parse: expected at least one field to unset",
+		},
 		{
 			query:  "map foo = 3 * (5 + 2 LOL",
 			errMsg: "failed to parse: failed to parse assignment: expected a closing paranthesis, but got \"LOL\"",
@@ -902,3 +1289,11 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -31,6 +31,14 @@ const (
 	TokenSort
 	TokenGroup
 	TokenMap
+	TokenLookup
+	TokenWindow
+	TokenBucket
+	TokenTop
+	TokenSample
+	TokenRename
+	TokenUnset
+	TokenSelect
 
 	// Punctuators
 	TokenLParen
@@ -64,6 +72,11 @@ const (
 	TokenEOF    Token = scanner.EOF
 )
 
+// IsStage returns whether the token is the name of a stage.
+func (t Token) IsStage() bool {
+	return t >= TokenFilter && t <= TokenSelect
+}
+
 func (t Token) String() string {
 	switch t {
 	case TokenStageSeparator:
@@ -76,6 +89,22 @@ func (t Token) String() string {
 		return "Group"
 	case TokenMap:
 		return "Map"
+	case TokenLookup:
+		return "Lookup"
+	case TokenWindow:
+		return "Window"
+	case TokenBucket:
+		return "Bucket"
+	case TokenTop:
+		return "Top"
+	case TokenSample:
+		return "Sample"
+	case TokenRename:
+		return "Rename"
+	case TokenUnset:
+		return "Unset"
+	case TokenSelect:
+		return "Select"
 	case TokenLParen:
 		return "LParen"
 	case TokenRParen:
@@ -166,8 +195,8 @@ func NewTokenizer(input string) Tokenizer {
 			return i == 0
 		case '|': // Treat pipe as an identifier.
 			return true
-		case '.': // If we see a '.' and it is the first position of the token, treat it as ident.
-			return i == 0
+		case '.': // Accept '.' anywhere in idents, for field references & paths (.a.b).
+			return true
 		}
 
 		// Digits are OK, but only if they are after the first character.
@@ -247,31 +276,35 @@ func (t *Tokenizer) next() Token {
 	return Token(tok)
 }
 
+// identTokens are the identifiers that are breeze-specific tokens of their own.
+var identTokens = map[string]Token{
+	StageSeparatorString: TokenStageSeparator,
+	"filter":             TokenFilter,
+	"sort":               TokenSort,
+	"group":              TokenGroup,
+	"map":                TokenMap,
+	"lookup":             TokenLookup,
+	"window":             TokenWindow,
+	"bucket":             TokenBucket,
+	"top":                TokenTop,
+	"sample":             TokenSample,
+	"rename":             TokenRename,
+	"unset":              TokenUnset,
+	"select":             TokenSelect,
+	"contains":           TokenContains,
+	"false":              TokenFalse,
+	"true":               TokenTrue,
+	"null":               TokenNull,
+}
+
 // Converts a token from the scanner into a breeze-specific Token type, if
 // possible.
 func (t *Tokenizer) convertIdentToken(tok rune) Token {
-	switch t.s.TokenText() {
-	case StageSeparatorString:
-		return TokenStageSeparator
-	case "filter":
-		return TokenFilter
-	case "sort":
-		return TokenSort
-	case "group":
-		return TokenGroup
-	case "map":
-		return TokenMap
-	case "contains":
-		return TokenContains
-	case "false":
-		return TokenFalse
-	case "true":
-		return TokenTrue
-	case "null":
-		return TokenNull
-	default:
-		return Token(tok)
+	if token, ok := identTokens[t.s.TokenText()]; ok {
+		return token
 	}
+
+	return Token(tok)
 }
 
 // Text wraps scanner.Scanner#TokenText().
@@ -12,7 +12,7 @@ import (
 // This is the expected number of 'custom' Breeze tokens (aka, tokens that are
 // not mapped to the ones found in the scanner package).
 // Note that this should always match the length of the below map.
-const expectedNumBreezeTokenTypes = 20
+const expectedNumBreezeTokenTypes = 28
 
 // This should always have a number of elements equal to the constant above.
 var tokenToExampleStr = map[breeze.Token]string{
@@ -21,6 +21,14 @@ var tokenToExampleStr = map[breeze.Token]string{
 	breeze.TokenSort:           "sort",
 	breeze.TokenGroup:          "group",
 	breeze.TokenMap:            "map",
+	breeze.TokenLookup:         "lookup",
+	breeze.TokenWindow:         "window",
+	breeze.TokenBucket:         "bucket",
+	breeze.TokenTop:            "top",
+	breeze.TokenSample:         "sample",
+	breeze.TokenRename:         "rename",
+	breeze.TokenUnset:          "unset",
+	breeze.TokenSelect:         "select",
 	breeze.TokenLParen:         "(",
 	breeze.TokenRParen:         ")",
 	breeze.TokenLSqBracket:     "[",
@@ -161,6 +169,18 @@ func TestTokenizerWithDotPrefixedTokens(t *testing.T) {
 	require.Equal(t, tokenizer.Text(), ".foo")
 }
 
+func TestTokenizerWithDottedPathTokens(t *testing.T) {
+	input := "hello .foo.bar"
+	tokenizer := breeze.NewTokenizer(input)
+
+	expectTokens(t, tokenizer, []breeze.Token{breeze.TokenIdent, breeze.TokenIdent})
+
+	tokenizer = breeze.NewTokenizer(input)
+	_ = tokenizer.Next()
+	_ = tokenizer.Next()
+	require.Equal(t, ".foo.bar", tokenizer.Text())
+}
+
 func TestTokenizerWithFunctionStyle(t *testing.T) {
 	input := "hello foo(2, \"hi\")"
 	tokenizer := breeze.NewTokenizer(input)
@@ -1,7 +1,9 @@
 package query
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/utagai/look/datum"
 )
@@ -9,7 +11,42 @@ import (
 var ErrUnableToParseQuery = errors.New("unable to parse the given query")
 
 // Executor executes a given query string on a set of datums and returns a
-// resulting set of datums.
+// resulting set of datums. Executors stop early and return the context's error
+// if the context is done before the query finishes.
 type Executor interface {
-	Find(q string, datums []datum.Datum) ([]datum.Datum, error)
+	Find(ctx context.Context, q string, datums []datum.Datum) ([]datum.Datum, error)
+}
+
+// ProfilingExecutor is an Executor that can also report how a query was
+// executed, stage by stage.
+type ProfilingExecutor interface {
+	Executor
+	FindProfiled(
+		ctx context.Context,
+		q string,
+		datums []datum.Datum,
+	) ([]datum.Datum, []StageStats, error)
+}
+
+// StageStats describes the work done by a single stage of a query.
+type StageStats struct {
+	// Stage is the name of the stage.
+	Stage string
+	// RowsIn is the number of datums the stage read.
+	RowsIn int
+	// RowsOut is the number of datums the stage returned.
+	RowsOut int
+	// Elapsed is the time spent in the stage itself, excluding the time spent in
+	// the stages before it.
+	Elapsed time.Duration
+}
+
+// MatchingExecutor is an Executor that can also report which parts of the
+// datums it finds matched the query, e.g. for highlighting them.
+type MatchingExecutor interface {
+	Executor
+	// Matcher returns a function that returns the spans of the JSON of a datum
+	// found by the given query, as given by datum.Datum.String(), that matched
+	// the query.
+	Matcher(q string) (func(datum.Datum) []datum.Span, error)
 }
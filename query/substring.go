@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"strings"
 
 	"github.com/utagai/look/datum"
@@ -8,13 +9,18 @@ import (
 
 type SubstringQueryExecutor struct{}
 
+var _ MatchingExecutor = (*SubstringQueryExecutor)(nil)
+
 func NewSubstringQueryExecutor() *SubstringQueryExecutor {
 	return &SubstringQueryExecutor{}
 }
 
-func (s *SubstringQueryExecutor) Find(q string, datums []datum.Datum) ([]datum.Datum, error) {
+func (s *SubstringQueryExecutor) Find(ctx context.Context, q string, datums []datum.Datum) ([]datum.Datum, error) {
 	newDatums := []datum.Datum{}
 	for _, datum := range datums {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if strings.Contains(datum.String(), q) {
 			newDatums = append(newDatums, datum)
 		}
@@ -22,3 +28,25 @@ func (s *SubstringQueryExecutor) Find(q string, datums []datum.Datum) ([]datum.D
 
 	return newDatums, nil
 }
+
+// Matcher implements the MatchingExecutor interface. Every occurrence of the
+// query in a datum matched.
+func (s *SubstringQueryExecutor) Matcher(q string) (func(datum.Datum) []datum.Span, error) {
+	return func(d datum.Datum) []datum.Span {
+		spans := []datum.Span{}
+		if q == "" {
+			return spans
+		}
+
+		jsonString := d.String()
+		for offset := 0; ; {
+			i := strings.Index(jsonString[offset:], q)
+			if i < 0 {
+				return spans
+			}
+			start := offset + i
+			spans = append(spans, datum.Span{Start: start, End: start + len(q)})
+			offset = start + len(q)
+		}
+	}, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/config"
+	"github.com/utagai/look/history"
+)
+
+// historySettleDelay is how long a query's results must stay on screen before
+// the query is recorded in the history. Queries run as they are typed, so this
+// keeps the partial queries typed on the way out of the history.
+const historySettleDelay = 2 * time.Second
+
+// queryHistory is the history of the queries run against the source.
+type queryHistory struct {
+	// history is nil if the history couldn't be loaded, in which case nothing
+	// is recalled or recorded.
+	history   *history.History
+	source    string
+	perSource bool
+}
+
+func newQueryHistory(cfg *config.Config) *queryHistory {
+	qh := &queryHistory{
+		source:    cfg.Source.Name(),
+		perSource: cfg.HistoryPerSource,
+	}
+	if abs, err := filepath.Abs(qh.source); err == nil {
+		qh.source = abs
+	}
+
+	path, err := history.DefaultPath()
+	if err != nil {
+		log.Printf("not using a history: %v", err)
+		return qh
+	}
+	qh.history, err = history.Load(path)
+	if err != nil {
+		log.Printf("not using a history: %v", err)
+	}
+
+	return qh
+}
+
+// ForSource returns the history of the queries run against another source,
+// which shares the history file.
+func (qh *queryHistory) ForSource(source string) *queryHistory {
+	other := *qh
+	other.source = source
+	if abs, err := filepath.Abs(source); err == nil {
+		other.source = abs
+	}
+
+	return &other
+}
+
+// Queries returns the queries to recall, oldest first.
+func (qh *queryHistory) Queries() []string {
+	if qh.history == nil {
+		return nil
+	}
+
+	if qh.perSource {
+		return qh.history.Queries(qh.source)
+	}
+	return qh.history.Queries("")
+}
+
+// Record records that the query was run.
+func (qh *queryHistory) Record(q string) {
+	if qh.history == nil || strings.TrimSpace(q) == "" {
+		return
+	}
+
+	if err := qh.history.Add(q, qh.source, time.Now()); err != nil {
+		log.Printf("failed to record %q in the history: %v", q, err)
+	}
+}
+
+// recall recalls queries from the history into the query textbox, either by
+// stepping through them, or by searching backwards through them like a shell's
+// reverse-i-search.
+type recall struct {
+	textbox *edit.Widget
+	// queries returns the queries of the history, oldest first.
+	queries func() []string
+
+	// snapshot is the history as it was when recalling started, and index is
+	// the position in it that is shown, or -1 if not recalling.
+	snapshot []string
+	index    int
+	// original is the text from before recalling started, and shown the text
+	// recalling last put in the textbox.
+	original string
+	shown    string
+
+	// searching is whether a search is in progress, and term what is being
+	// searched for.
+	searching bool
+	term      string
+	// keys are the keys of the actions, of which the search key finds the next
+	// older match.
+	keys *keymap
+}
+
+func newRecall(textbox *edit.Widget, queries func() []string, keys *keymap) *recall {
+	return &recall{
+		textbox: textbox,
+		queries: queries,
+		index:   -1,
+		keys:    keys,
+	}
+}
+
+// recalling returns whether the textbox still shows a recalled query. Editing
+// the query ends the recall.
+func (r *recall) recalling() bool {
+	return r.index != -1 && r.textbox.Text() == r.shown
+}
+
+func (r *recall) start() {
+	if r.recalling() {
+		return
+	}
+
+	r.snapshot = r.queries()
+	r.index = len(r.snapshot)
+	r.original = r.textbox.Text()
+	r.shown = r.original
+}
+
+func (r *recall) show(app gowid.IApp, index int) {
+	r.index = index
+	r.shown = r.original
+	if index < len(r.snapshot) {
+		r.shown = r.snapshot[index]
+	}
+	setQuery(app, r.textbox, r.shown)
+}
+
+// Older shows the query before the one shown, if any.
+func (r *recall) Older(app gowid.IApp) {
+	r.start()
+	if r.index > 0 {
+		r.show(app, r.index-1)
+	}
+}
+
+// Newer shows the query after the one shown, and eventually the query from
+// before recalling started. It returns false if not recalling, so that the key
+// can move on to the results instead.
+func (r *recall) Newer(app gowid.IApp) bool {
+	if !r.recalling() || r.index == len(r.snapshot) {
+		r.index = -1
+		return false
+	}
+
+	r.show(app, r.index+1)
+	return true
+}
+
+// Search starts a reverse incremental search.
+func (r *recall) Search() string {
+	r.start()
+	r.searching = true
+	r.term = ""
+
+	return r.searchHint(true)
+}
+
+// Searching returns whether a search is in progress, in which case keys should
+// go to HandleSearchKey.
+func (r *recall) Searching() bool {
+	return r.searching
+}
+
+// HandleSearchKey handles a key during a search, and returns whether it did,
+// along with a hint about the search for the status bar. Typing extends the
+// term, Backspace shortens it, and the search key finds the next older match.
+// Enter accepts the match, and Esc or Ctrl-G cancels the search. Other keys
+// accept the match, but are left to be handled as usual.
+func (r *recall) HandleSearchKey(app gowid.IApp, ev *tcell.EventKey) (bool, string) {
+	if a, bound := r.keys.Action(ev); bound && a == actionSearch {
+		return true, r.searchHint(r.find(app, r.index-1))
+	}
+
+	switch ev.Key() {
+	case tcell.KeyRune:
+		r.term += string(ev.Rune())
+		// The match shown may well still match.
+		return true, r.searchHint(r.find(app, r.index))
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r.term != "" {
+			runes := []rune(r.term)
+			r.term = string(runes[:len(runes)-1])
+		}
+		return true, r.searchHint(r.find(app, r.index))
+	case tcell.KeyEsc, tcell.KeyCtrlG:
+		r.searching = false
+		r.show(app, len(r.snapshot))
+		r.index = -1
+		return true, ""
+	case tcell.KeyEnter:
+		r.searching = false
+		return true, ""
+	default:
+		r.searching = false
+		return false, ""
+	}
+}
+
+// find shows the newest query at or before the given index that contains the
+// term, and returns whether there is one. The shown query is kept if not.
+func (r *recall) find(app gowid.IApp, from int) bool {
+	if from >= len(r.snapshot) {
+		from = len(r.snapshot) - 1
+	}
+
+	for i := from; i >= 0; i-- {
+		if strings.Contains(r.snapshot[i], r.term) {
+			r.show(app, i)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *recall) searchHint(found bool) string {
+	if !found && r.term != "" {
+		return fmt.Sprintf("(failed reverse-i-search)`%s'", r.term)
+	}
+
+	return fmt.Sprintf("(reverse-i-search)`%s' %s older, Enter accepts, ESC cancels", r.term, r.keys.Label(actionSearch))
+}
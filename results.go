@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+)
+
+// resultsView shows the results of the latest query, either as a list of JSON
+// lines or as a table.
+type resultsView struct {
+	*holder.Widget
+	list      *list.IndexedWidget
+	table     *tableView
+	newWalker func(data.Data) *data.DataWalker
+	tableMode bool
+}
+
+func newResultsView(d data.Data, newWalker func(data.Data) *data.DataWalker) *resultsView {
+	lb := list.NewBounded(newWalker(d))
+	return &resultsView{
+		Widget:    holder.New(styled.New(lb, gowid.MakePaletteRef("body"))),
+		list:      lb,
+		table:     newTableView(newWalker(d)),
+		newWalker: newWalker,
+	}
+}
+
+// SetData shows the given data, which are the results of the given query.
+func (r *resultsView) SetData(app gowid.IApp, d data.Data, q string) {
+	r.list.SetWalker(r.newWalker(d), app)
+	r.table.SetWalker(r.newWalker(d), q, app)
+}
+
+// Walker returns the walker of the view that is showing.
+func (r *resultsView) Walker() *data.DataWalker {
+	if r.tableMode {
+		return r.table.lb.Walker().(*data.DataWalker)
+	}
+
+	return r.list.Walker().(*data.DataWalker)
+}
+
+// ToggleTable switches between the list and the table, keeping the focus on
+// the same datum.
+func (r *resultsView) ToggleTable(app gowid.IApp) {
+	focus := r.Walker().Focus()
+	r.tableMode = !r.tableMode
+	r.Walker().SetFocus(focus, app)
+
+	if r.tableMode {
+		r.SetSubWidget(styled.New(r.table, gowid.MakePaletteRef("body")), app)
+	} else {
+		r.SetSubWidget(styled.New(r.list, gowid.MakePaletteRef("body")), app)
+	}
+}
+
+// HandleTableAction takes the action on the columns of the table, and returns
+// whether it did, which it only does while the table is showing.
+func (r *resultsView) HandleTableAction(a action) bool {
+	return r.tableMode && r.table.HandleAction(a)
+}
+
+// Focused returns the index of the datum in focus, along with the datum.
+func (r *resultsView) Focused() (int, datum.Datum, error) {
+	walker := r.Walker()
+	index := int(walker.Focus().(list.ListPos))
+	d, err := walker.Data().At(context.Background(), index)
+	return index, d, err
+}
+
+// FocusedColumn returns the column in focus, if the table is showing.
+func (r *resultsView) FocusedColumn() (string, bool) {
+	if !r.tableMode || r.table.layout.focus == "" {
+		return "", false
+	}
+
+	return r.table.layout.focus, true
+}
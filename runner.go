@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+)
+
+const (
+	// queryDebounce is how long the query must stay unchanged before we run it.
+	// This avoids running a query for every keystroke while the user is typing.
+	queryDebounce = 150 * time.Millisecond
+	// spinnerInterval is how often the progress of a running query is reported.
+	spinnerInterval = 100 * time.Millisecond
+	// numSampleDatums is the number of results sampled for the views of the
+	// results as a whole, like the schema and the chart, which keeps them quick
+	// on large results.
+	numSampleDatums = 1000
+)
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// spinner returns the spinner frame to show after the given amount of time.
+func spinner(elapsed time.Duration) rune {
+	return spinnerFrames[int(elapsed/spinnerInterval)%len(spinnerFrames)]
+}
+
+// queryResult is the outcome of a query run by a queryRunner.
+type queryResult struct {
+	query  string
+	data   data.Data
+	length int
+	// sample is the first of the results, if the runner samples them.
+	sample  []datum.Datum
+	err     error
+	elapsed time.Duration
+}
+
+// queryRunner runs queries against the data on a background goroutine, so that
+// the UI stays responsive while they run. Submitting a query cancels the query
+// that came before it, whether it is still waiting out the debounce or already
+// running.
+//
+// The callbacks are always invoked on the UI goroutine, and are never invoked
+// for a query that has been superseded.
+type queryRunner struct {
+	data data.Data
+	// sampleSize is the number of results sampled for each result.
+	sampleSize int
+	// onRunning is periodically invoked while a query is running.
+	onRunning func(app gowid.IApp, elapsed time.Duration)
+	// onDone is invoked once a query finishes.
+	onDone func(app gowid.IApp, result queryResult)
+
+	cancel context.CancelFunc
+}
+
+func newQueryRunner(
+	d data.Data,
+	sampleSize int,
+	onRunning func(gowid.IApp, time.Duration),
+	onDone func(gowid.IApp, queryResult),
+) *queryRunner {
+	return &queryRunner{
+		data:       d,
+		sampleSize: sampleSize,
+		onRunning:  onRunning,
+		onDone:     onDone,
+	}
+}
+
+// Submit runs the given query after the debounce period. It must be called from
+// the UI goroutine.
+func (r *queryRunner) Submit(app gowid.IApp, q string) {
+	r.Cancel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.run(ctx, app, q)
+}
+
+// Cancel cancels the query submitted last, whether it is still waiting out the
+// debounce or already running. It must be called from the UI goroutine.
+func (r *queryRunner) Cancel() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+func (r *queryRunner) run(ctx context.Context, app gowid.IApp, q string) {
+	select {
+	case <-time.After(queryDebounce):
+	case <-ctx.Done():
+		return
+	}
+
+	start := time.Now()
+	done := make(chan queryResult, 1)
+	go func() {
+		result := queryResult{query: q}
+		result.data, result.err = r.data.Find(ctx, q)
+		// Since some backends have to do real work to find the length and the
+		// datums, do so here rather than on the UI goroutine.
+		if result.err == nil {
+			result.length, result.err = result.data.Length(ctx)
+		}
+		if result.err == nil {
+			result.sample = sample(ctx, result.data, r.sampleSize)
+		}
+		result.elapsed = time.Since(start)
+		done <- result
+	}()
+
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			elapsed := time.Since(start)
+			r.runOnUI(ctx, app, func(app gowid.IApp) {
+				r.onRunning(app, elapsed)
+			})
+		case result := <-done:
+			r.runOnUI(ctx, app, func(app gowid.IApp) {
+				r.onDone(app, result)
+			})
+			return
+		}
+	}
+}
+
+// runOnUI runs f on the UI goroutine, unless the query has been superseded by
+// the time f gets to run. Since Submit is also called from the UI goroutine, a
+// superseded query can never clobber the results of the query after it.
+func (r *queryRunner) runOnUI(ctx context.Context, app gowid.IApp, f func(gowid.IApp)) {
+	// This only fails if the app is shutting down, in which case there is
+	// nothing left to update.
+	_ = app.Run(gowid.RunFunction(func(app gowid.IApp) {
+		if ctx.Err() != nil {
+			return
+		}
+		f(app)
+	}))
+}
+
+// sample returns the first n datums of the data, or as many as it can read.
+func sample(ctx context.Context, d data.Data, n int) []datum.Datum {
+	datums := make([]datum.Datum, 0, n)
+	for i := 0; i < n; i++ {
+		sampled, err := d.At(ctx, i)
+		if err != nil {
+			// Either we ran out of datums, or the data is failing, in which case
+			// whatever we have so far will do.
+			break
+		}
+		datums = append(datums, sampled)
+	}
+
+	return datums
+}
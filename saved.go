@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gdamore/tcell"
+)
+
+const savedHint = "Enter runs the query, ESC closes."
+
+// savedView is a modal that lists the saved queries, to pick one to run.
+type savedView struct {
+	gowid.IWidget
+	walker  *list.SimpleListWalker
+	names   []string
+	queries map[string]string
+	onPick  func(app gowid.IApp, query string)
+	onClose func(app gowid.IApp)
+}
+
+func newSavedView(queries map[string]string, onPick func(gowid.IApp, string), onClose func(gowid.IApp)) *savedView {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	widgets := make([]gowid.IWidget, 0, len(names))
+	for _, name := range names {
+		widgets = append(widgets, selectable.New(
+			styled.NewExt(
+				text.New(fmt.Sprintf("%s — %s", name, queries[name])),
+				gowid.MakePaletteRef("modal"), gowid.MakePaletteRef("fmodal"),
+			),
+		))
+	}
+	walker := list.NewSimpleListWalker(widgets)
+
+	var body gowid.IWidget = list.New(walker)
+	if len(names) == 0 {
+		body = text.New("There are no saved queries. Save them under queries in the config file.")
+	}
+
+	view := pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: body,
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: styled.New(text.New(savedHint), gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+	})
+
+	return &savedView{
+		IWidget: framed.New(view, framed.Options{
+			Frame: framed.UnicodeFrame,
+			Title: "saved queries",
+		}),
+		walker:  walker,
+		names:   names,
+		queries: queries,
+		onPick:  onPick,
+		onClose: onClose,
+	}
+}
+
+// UserInput implements the gowid.IWidget interface. The view is a modal, so it
+// swallows every key, rather than letting them through to the widgets beneath.
+func (v *savedView) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	switch {
+	case evk.Key() == tcell.KeyEsc || evk.Rune() == 'q':
+		v.onClose(app)
+	case evk.Key() == tcell.KeyEnter:
+		if len(v.names) == 0 {
+			v.onClose(app)
+			break
+		}
+		name := v.names[int(v.walker.Focus().(list.ListPos))]
+		v.onClose(app)
+		v.onPick(app, v.queries[name])
+	default:
+		v.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	return true
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/styled"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/datum"
+)
+
+const (
+	numSchemaTopValues = 3
+	// maxSchemaValueWidth caps the width of the top values, so that a long
+	// string doesn't crowd out the rest.
+	maxSchemaValueWidth = 20
+)
+
+// schemaSidebar shows the fields of the results, along with their types, how
+// often they are null or missing, and their most common values. Enter inserts
+// the field in focus into the query.
+type schemaSidebar struct {
+	*holder.Widget
+	// sample is the first of the results the schema is of, and length the
+	// number of results.
+	sample []datum.Datum
+	length int
+	fields []*datum.FieldSchema
+	walker *list.SimpleListWalker
+	onPick func(app gowid.IApp, field string)
+	// shown is whether the sidebar is showing, since the schema is only worth
+	// taking if it is.
+	shown bool
+}
+
+func newSchemaSidebar(onPick func(gowid.IApp, string)) *schemaSidebar {
+	return &schemaSidebar{
+		Widget: holder.New(text.New("")),
+		onPick: onPick,
+	}
+}
+
+// SetData sets the results the schema is of, by their first datums and their
+// number.
+func (s *schemaSidebar) SetData(app gowid.IApp, sample []datum.Datum, length int) {
+	s.sample = sample
+	s.length = length
+	if s.shown {
+		s.refresh(app)
+	}
+}
+
+// SetShown sets whether the sidebar is showing, and takes the schema if it now
+// is.
+func (s *schemaSidebar) SetShown(app gowid.IApp, shown bool) {
+	s.shown = shown
+	if shown {
+		s.refresh(app)
+	}
+}
+
+func (s *schemaSidebar) refresh(app gowid.IApp) {
+	schema := datum.NewSchema(s.sample, numSchemaTopValues)
+
+	title := fmt.Sprintf("schema of %s", plural(schema.Datums, "result"))
+	if s.length > schema.Datums {
+		title = fmt.Sprintf("schema of the first %d of %d results", schema.Datums, s.length)
+	}
+
+	s.fields = schema.Fields
+	widgets := make([]gowid.IWidget, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		widgets = append(widgets, selectable.New(
+			styled.NewExt(
+				text.New(describeField(field, schema.Datums)),
+				gowid.MakePaletteRef("body"), gowid.MakePaletteRef("fbody"),
+			),
+		))
+	}
+	s.walker = list.NewSimpleListWalker(widgets)
+
+	s.SetSubWidget(pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: styled.New(text.New(title), gowid.MakePaletteRef("foot")),
+			D:       gowid.RenderFlow{},
+		},
+		&gowid.ContainerWidget{
+			IWidget: list.New(s.walker),
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+	}), app)
+}
+
+// UserInput implements the gowid.IWidget interface.
+func (s *schemaSidebar) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok || evk.Key() != tcell.KeyEnter || len(s.fields) == 0 {
+		return s.Widget.UserInput(ev, size, focus, app)
+	}
+
+	field := s.fields[int(s.walker.Focus().(list.ListPos))]
+	s.onPick(app, fieldRef(field.Path))
+	return true
+}
+
+// fieldRef returns the reference to the field at the given path in a query, or
+// an empty string if its keys can't be written in a query.
+func fieldRef(path []string) string {
+	for _, key := range path {
+		if !simpleKeyRegex.MatchString(key) {
+			return ""
+		}
+	}
+
+	return "." + strings.Join(path, ".")
+}
+
+// describeField describes the field, out of the given number of datums, e.g.
+//
+//	.latency
+//	  number 98%, string 2%
+//	  null 1%, missing 0%
+//	  top: 12 (40), 15 (10)
+func describeField(field *datum.FieldSchema, datums int) string {
+	var sb strings.Builder
+	sb.WriteString("." + strings.Join(field.Path, "."))
+
+	// The types are out of the values that are there and not null.
+	present := 0
+	for valueType, count := range field.Types {
+		if valueType != datum.ValueTypeNull {
+			present += count
+		}
+	}
+	if present > 0 {
+		valueTypes := make([]datum.ValueType, 0, len(field.Types))
+		for valueType := range field.Types {
+			if valueType != datum.ValueTypeNull {
+				valueTypes = append(valueTypes, valueType)
+			}
+		}
+		sort.Slice(valueTypes, func(i, j int) bool {
+			if field.Types[valueTypes[i]] != field.Types[valueTypes[j]] {
+				return field.Types[valueTypes[i]] > field.Types[valueTypes[j]]
+			}
+			return valueTypes[i] < valueTypes[j]
+		})
+
+		types := make([]string, 0, len(valueTypes))
+		for _, valueType := range valueTypes {
+			types = append(types, fmt.Sprintf("%s %s", valueType, percent(field.Types[valueType], present)))
+		}
+		sb.WriteString("\n  " + strings.Join(types, ", "))
+	}
+
+	fmt.Fprintf(&sb, "\n  null %s, missing %s",
+		percent(field.Types[datum.ValueTypeNull], datums), percent(field.Missing, datums))
+
+	switch {
+	case len(field.TopValues) == 0:
+	case field.TopValues[0].Count == 1 && len(field.TopValues) > 1:
+		// The most common values are no more common than any other.
+		sb.WriteString("\n  all values distinct")
+	default:
+		top := make([]string, 0, len(field.TopValues))
+		for _, value := range field.TopValues {
+			top = append(top, fmt.Sprintf("%s (%d)", truncate(value.Value, maxSchemaValueWidth), value.Count))
+		}
+		sb.WriteString("\n  top: " + strings.Join(top, ", "))
+	}
+
+	return sb.String()
+}
+
+// percent returns n out of total as a percentage, rounded, but never to 0% or
+// 100% unless it is exactly that.
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+
+	p := math.Round(100 * float64(n) / float64(total))
+	switch {
+	case p == 0 && n > 0:
+		return "<1%"
+	case p == 100 && n < total:
+		return ">99%"
+	}
+	return fmt.Sprintf("%d%%", int(p))
+}
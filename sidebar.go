@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/columns"
+	"github.com/gcla/gowid/widgets/fill"
+	"github.com/gcla/gowid/widgets/holder"
+)
+
+const sidebarWidth = 40
+
+// sidebar is a pane shown beside the results.
+type sidebar interface {
+	gowid.IWidget
+	// SetShown is called when the sidebar is shown or hidden, so that it only
+	// does its work while it is showing.
+	SetShown(app gowid.IApp, shown bool)
+}
+
+// dimensioned is implemented by sidebars that take other than sidebarWidth
+// columns, e.g. a share of the width.
+type dimensioned interface {
+	Dimension() gowid.IWidgetDimension
+}
+
+// sidebarLayout shows one of the sidebars to the right of the main widget, or
+// none.
+type sidebarLayout struct {
+	*holder.Widget
+	main gowid.IWidget
+	// sidebar is the sidebar showing, if any, and cols lays it out.
+	sidebar sidebar
+	cols    *columns.Widget
+}
+
+func newSidebarLayout(main gowid.IWidget) *sidebarLayout {
+	return &sidebarLayout{
+		Widget: holder.New(main),
+		main:   main,
+	}
+}
+
+// Toggle shows the sidebar, or hides it if it is already showing. A sidebar
+// that can be interacted with is put in focus.
+func (l *sidebarLayout) Toggle(app gowid.IApp, s sidebar) {
+	if l.sidebar != nil {
+		l.sidebar.SetShown(app, false)
+	}
+	if l.sidebar == s {
+		l.sidebar = nil
+		l.SetSubWidget(l.main, app)
+		return
+	}
+
+	l.sidebar = s
+	s.SetShown(app, true)
+	var dimension gowid.IWidgetDimension = gowid.RenderWithUnits{U: sidebarWidth}
+	if d, ok := s.(dimensioned); ok {
+		dimension = d.Dimension()
+	}
+	l.cols = columns.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: l.main,
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: fill.New('│'),
+			D:       gowid.RenderWithUnits{U: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: s,
+			D:       dimension,
+		},
+	})
+	if s.Selectable() {
+		l.cols.SetFocus(app, 2)
+	}
+	l.SetSubWidget(l.cols, app)
+}
+
+// Showing returns whether the sidebar is showing.
+func (l *sidebarLayout) Showing(s sidebar) bool {
+	return l.sidebar == s
+}
+
+// Focused returns whether the sidebar is showing and in focus.
+func (l *sidebarLayout) Focused(s sidebar) bool {
+	return l.sidebar == s && l.cols.Focus() == 2
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/query"
+)
+
+// statusBar shows the status of the latest query: its progress while it runs,
+// and then either its error or a summary of its results, which can be expanded
+// into a per-stage breakdown.
+type statusBar struct {
+	*holder.Widget
+	textbox *text.Widget
+	valid   gowid.IWidget
+	invalid gowid.IWidget
+
+	// result is the result of the latest successful query, if any.
+	result *queryResult
+	// expanded is whether the per-stage breakdown of the result is shown.
+	expanded bool
+	// text is the status, and hint is shown above it, if set.
+	text string
+	hint string
+	// keys name the keys in the status.
+	keys *keymap
+}
+
+func newStatusBar(keys *keymap) *statusBar {
+	const initial = "Done."
+	textbox := text.New(initial)
+	valid := framed.New(textbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorGreen),
+	})
+	invalid := framed.New(textbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorRed),
+	})
+
+	return &statusBar{
+		Widget:  holder.New(valid),
+		textbox: textbox,
+		valid:   valid,
+		invalid: invalid,
+		text:    initial,
+		keys:    keys,
+	}
+}
+
+// SetRunning reports that a query has been running for the given time.
+func (s *statusBar) SetRunning(app gowid.IApp, elapsed time.Duration) {
+	s.setText(app, fmt.Sprintf("%c running… %.1fs", spinner(elapsed), elapsed.Seconds()))
+}
+
+// SetError reports that the latest query failed.
+func (s *statusBar) SetError(app gowid.IApp, err error) {
+	s.SetSubWidget(s.invalid, app)
+	s.setText(app, err.Error())
+}
+
+// SetFailure reports that the latest query failed for reasons other than the
+// query itself, and so may succeed if retried.
+func (s *statusBar) SetFailure(app gowid.IApp, err error) {
+	s.SetError(app, err)
+	if key := s.keys.Label(actionRerun); key != "" {
+		s.setText(app, fmt.Sprintf("%s\n%s retries the query.", s.text, key))
+	}
+}
+
+// SetHint shows a hint above the status, e.g. about the completion of the
+// query, until it is cleared with an empty hint. Unlike the status, it stays
+// while queries run.
+func (s *statusBar) SetHint(app gowid.IApp, hint string) {
+	if hint == s.hint {
+		return
+	}
+	s.hint = hint
+	s.setText(app, s.text)
+}
+
+// SetResult reports the result of the latest query.
+func (s *statusBar) SetResult(app gowid.IApp, result queryResult) {
+	s.result = &result
+	s.SetSubWidget(s.valid, app)
+	s.render(app)
+}
+
+// ToggleBreakdown expands or collapses the per-stage breakdown of the result.
+func (s *statusBar) ToggleBreakdown(app gowid.IApp) {
+	s.expanded = !s.expanded
+	if s.result != nil {
+		s.render(app)
+	}
+}
+
+func (s *statusBar) render(app gowid.IApp) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d results in %v.", s.result.length, roundDuration(s.result.elapsed))
+
+	var profile []query.StageStats
+	if profiled, ok := s.result.data.(data.Profiled); ok {
+		profile = profiled.Profile()
+	}
+	if len(profile) > 0 {
+		key := s.keys.Label(actionStages)
+		if s.expanded {
+			if key != "" {
+				fmt.Fprintf(&sb, " (%s hides the stage breakdown)", key)
+			}
+			sb.WriteString("\n")
+			writeBreakdown(&sb, profile)
+		} else if key != "" {
+			fmt.Fprintf(&sb, " (%s shows the stage breakdown)", key)
+		}
+	}
+
+	s.setText(app, strings.TrimSuffix(sb.String(), "\n"))
+}
+
+func (s *statusBar) setText(app gowid.IApp, text string) {
+	s.text = text
+	if s.hint != "" {
+		text = s.hint + "\n" + text
+	}
+	s.textbox.SetText(text, app)
+}
+
+// writeBreakdown writes a table of the given stage stats.
+func writeBreakdown(sb *strings.Builder, profile []query.StageStats) {
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "stage\tin\tout\ttime")
+	for _, stats := range profile {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\n", stats.Stage, stats.RowsIn, stats.RowsOut, roundDuration(stats.Elapsed))
+	}
+	// Writing to a strings.Builder can't fail.
+	_ = tw.Flush()
+}
+
+// roundDuration rounds the duration to a precision that is useful to a human.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/edit"
+	"github.com/gcla/gowid/widgets/framed"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/vpadding"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/config"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/internal/generics"
+	"github.com/utagai/look/query"
+)
+
+// maxTabQueryWidth caps the width of the queries in the labels of the tabs.
+const maxTabQueryWidth = 24
+
+// tabEnv is what the tabs share.
+type tabEnv struct {
+	app     *gowid.App
+	cfg     *config.Config
+	keys    *keymap
+	history *queryHistory
+	// root holds the view, and any modal shown over it.
+	root *holder.Widget
+	loop *mainLoop
+	// onChange is called when the label of a tab changes.
+	onChange func(app gowid.IApp)
+}
+
+// source is the data of a source, which a tab shares with the tabs duplicated
+// from it. Its data is closed once the last of them closes.
+type source struct {
+	name string
+	data data.Data
+	// fields are the fields of a sample of all of the data, once a tab has run
+	// a query for all of it.
+	fields []string
+	// tabs is the number of tabs of the source.
+	tabs int
+}
+
+func newSource(name string, d data.Data) *source {
+	return &source{name: name, data: d}
+}
+
+// release lets go of the source for a tab that is closing, and closes its data
+// if no other tab has it.
+func (s *source) release() {
+	s.tabs--
+	if s.tabs > 0 {
+		return
+	}
+
+	if closer, ok := s.data.(data.Closer); ok {
+		if err := closer.Close(context.Background()); err != nil {
+			log.Printf("failed to close %s: %v", s.name, err)
+		}
+	}
+}
+
+// tab is a session of its own: a source, a query against it, and the views of
+// its results. The tabs keep their state while others are showing, so that
+// e.g. a grouped overview can stay open while another tab drills into the raw
+// results.
+type tab struct {
+	env    *tabEnv
+	source *source
+
+	view          *pile.Widget
+	queryTextbox  *edit.Widget
+	status        *statusBar
+	results       *resultsView
+	resultsLayout *sidebarLayout
+	schema        *schemaSidebar
+	charts        *chartPane
+	compare       *comparePane
+	runner        *queryRunner
+	history       *queryHistory
+	recall        *recall
+	completer     *completer
+	prompt        *prompt
+	finder        *finder
+
+	// marked is the result marked to diff others against, if any, and
+	// markedIndex its index in the results it was marked in. The mark is
+	// cleared once the results are of another query.
+	marked      datum.Datum
+	markedIndex int
+	// resultFields are the fields of the sample of the latest results.
+	resultFields []string
+	// lastQuery is the latest query that succeeded. It is recorded in the
+	// history once its results have settled, or on exit.
+	lastQuery string
+	settle    *time.Timer
+}
+
+// newTab returns a tab of the source, which runs the given query once the app
+// is running.
+func newTab(env *tabEnv, src *source, q string) *tab {
+	src.tabs++
+	d := src.data
+	t := &tab{
+		env:     env,
+		source:  src,
+		status:  newStatusBar(env.keys),
+		history: env.history.ForSource(src.name),
+	}
+	t.results = newResultsView(d, t.newWalker)
+
+	t.queryTextbox = edit.New(edit.Options{Caption: "Query: "})
+	framedQueryTextboxValid := framed.New(t.queryTextbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorGreen),
+	})
+	framedQueryTextboxInvalid := framed.New(t.queryTextbox, framed.Options{
+		Frame: framed.UnicodeFrame,
+		Style: gowid.MakeForeground(gowid.ColorRed),
+	})
+	queryTextboxHolder := holder.New(framedQueryTextboxValid)
+
+	t.runner = newQueryRunner(
+		d,
+		numSampleDatums,
+		t.status.SetRunning,
+		func(app gowid.IApp, result queryResult) {
+			if errors.Is(result.err, query.ErrUnableToParseQuery) {
+				log.Printf("incomplete query: %q", result.query)
+				queryTextboxHolder.SetSubWidget(framedQueryTextboxInvalid, app)
+				t.status.SetError(app, result.err)
+				return
+			}
+
+			queryTextboxHolder.SetSubWidget(framedQueryTextboxValid, app)
+			if result.err != nil {
+				// Keep the previous results on screen, since the failure may well
+				// be transient.
+				log.Printf("failed to run query %q: %v", result.query, result.err)
+				t.status.SetFailure(app, result.err)
+				return
+			}
+			t.status.SetResult(app, result)
+			t.results.SetData(app, result.data, result.query)
+			t.schema.SetData(app, result.sample, result.length)
+			t.charts.SetData(app, result.sample, result.query)
+			t.compare.SetBaseline(app, result.data)
+			t.resultFields = sampleFields(result.sample)
+			if result.query == "" {
+				t.source.fields = t.resultFields
+			}
+
+			if result.query != t.lastQuery {
+				t.marked = nil
+			}
+			t.lastQuery = result.query
+			env.onChange(app)
+			if t.settle != nil {
+				t.settle.Stop()
+			}
+			t.settle = time.AfterFunc(historySettleDelay, func() {
+				_ = app.Run(gowid.RunFunction(func(app gowid.IApp) {
+					// The query may have changed since, without its results
+					// having arrived yet.
+					if t.queryTextbox.Text() == result.query {
+						t.history.Record(result.query)
+					}
+				}))
+			})
+		},
+	)
+	t.queryTextbox.OnTextSet(gowid.WidgetCallback{
+		Name: "on query text change",
+		WidgetChangedFunction: func(app gowid.IApp, w gowid.IWidget) {
+			t.runner.Submit(app, t.queryTextbox.Text())
+		},
+	})
+
+	t.recall = newRecall(t.queryTextbox, t.history.Queries, env.keys)
+
+	// The fields of the source are always offered, along with those of the
+	// current results, which may have new ones.
+	t.completer = newCompleter(t.queryTextbox, func() []string {
+		fields := append([]string{}, t.source.fields...)
+		for _, field := range t.resultFields {
+			if !generics.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+		return fields
+	}, env.keys)
+
+	t.schema = newSchemaSidebar(func(app gowid.IApp, field string) {
+		if field == "" {
+			t.status.SetHint(app, "That field can't be written in a query.")
+			return
+		}
+		insertIntoQuery(app, t.queryTextbox, field)
+		t.view.SetFocus(app, 0)
+	})
+	t.charts = newChartPane()
+	t.compare = newComparePane(d, t.newWalker)
+	t.resultsLayout = newSidebarLayout(t.results)
+	t.prompt = newPrompt(t.status.SetHint)
+	t.finder = newFinder(t.results, t.prompt, t.status.SetHint)
+
+	t.view = pile.New([]gowid.IContainerWidget{
+		&gowid.ContainerWidget{
+			IWidget: vpadding.New(queryTextboxHolder, gowid.VAlignMiddle{}, gowid.RenderFlow{}),
+			D:       gowid.RenderFlow{},
+		},
+		&gowid.ContainerWidget{
+			IWidget: t.resultsLayout,
+			D:       gowid.RenderWithWeight{W: 1},
+		},
+		&gowid.ContainerWidget{
+			IWidget: vpadding.New(t.status, gowid.VAlignMiddle{}, gowid.RenderFlow{}),
+			D:       gowid.RenderFlow{},
+		},
+	})
+
+	// The query runs once the app is running, even if it is empty, so that the
+	// views of the results as a whole get a sample of them.
+	_ = env.app.Run(gowid.RunFunction(func(app gowid.IApp) {
+		if q == "" {
+			t.runner.Submit(app, q)
+			return
+		}
+		// The textbox submits the query.
+		setQuery(app, t.queryTextbox, q)
+	}))
+
+	return t
+}
+
+func (t *tab) newWalker(d data.Data) *data.DataWalker {
+	walker := data.NewDataWalker(d)
+	var matches func(datum.Datum) []datum.Span
+	if matched, ok := d.(data.Matched); ok {
+		matches = matched.Matches
+	}
+	walker.SetRenderer(newDatumRenderer(t.env.cfg.Pretty, matches))
+	walker.OnError(func(err error) {
+		// Walkers are called while rendering, so defer changing the view.
+		_ = t.env.app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			t.status.SetFailure(app, err)
+		}))
+	})
+	return walker
+}
+
+// Label returns the label of the tab in the tab bar: the name of its source,
+// and its query, if any.
+func (t *tab) Label() string {
+	if t.lastQuery == "" {
+		return tabName(t.source.name)
+	}
+
+	return fmt.Sprintf("%s: %s", tabName(t.source.name), truncate(t.lastQuery, maxTabQueryWidth))
+}
+
+// Close stops the queries of the tab, records its last query in the history,
+// and closes its source, unless another tab has it.
+func (t *tab) Close() {
+	t.runner.Cancel()
+	t.compare.Cancel()
+	if t.settle != nil {
+		t.settle.Stop()
+	}
+	t.history.Record(t.lastQuery)
+	t.source.release()
+}
+
+// HandleKey handles the keys of the tab, and returns whether it did. It gets
+// the first chance at the keys, since e.g. the query textbox would otherwise
+// take Enter as a newline.
+func (t *tab) HandleKey(app gowid.IApp, ev *tcell.EventKey) bool {
+	if t.prompt.Active() {
+		t.prompt.HandleKey(app, ev)
+		return true
+	}
+	if t.recall.Searching() {
+		handled, hint := t.recall.HandleSearchKey(app, ev)
+		t.status.SetHint(app, hint)
+		if handled {
+			return true
+		}
+	}
+	a, bound := t.env.keys.Action(ev)
+	if a != actionComplete {
+		t.status.SetHint(app, "")
+	}
+
+	// Some keys are only for the query textbox, and others only for the
+	// results.
+	typing := t.view.Focus() == 0
+	if t.view.Focus() == 1 && !t.resultsLayout.Focused(t.schema) && !t.resultsLayout.Focused(t.compare) {
+		if t.finder.HandleKey(app, ev) {
+			return true
+		}
+		if bound && t.handleResultAction(app, a) {
+			return true
+		}
+	}
+	switch ev.Key() {
+	case tcell.KeyEsc:
+		// Esc stops a search, rather than exiting.
+		return t.finder.Cancel(app)
+	case tcell.KeyUp:
+		if !typing {
+			return false
+		}
+		t.recall.Older(app)
+		return true
+	case tcell.KeyDown:
+		// Once past the newest query, Down moves on to the results.
+		return typing && t.recall.Newer(app)
+	}
+	if !bound {
+		return false
+	}
+
+	switch a {
+	case actionStages:
+		t.status.ToggleBreakdown(app)
+	case actionTable:
+		t.results.ToggleTable(app)
+	case actionSaved:
+		showSaved(app, t.env.cfg.SavedQueries, t.queryTextbox, t.env.root)
+	case actionRerun:
+		t.runner.Submit(app, t.queryTextbox.Text())
+	case actionSchema:
+		t.resultsLayout.Toggle(app, t.schema)
+		if t.resultsLayout.Focused(t.schema) {
+			t.view.SetFocus(app, 1)
+		}
+	case actionChart:
+		t.resultsLayout.Toggle(app, t.charts)
+	case actionCompare:
+		t.resultsLayout.Toggle(app, t.compare)
+		if t.resultsLayout.Focused(t.compare) {
+			t.view.SetFocus(app, 1)
+		}
+	case actionDiff:
+		if !t.resultsLayout.Showing(t.compare) {
+			t.status.SetHint(app, "Compare two queries to see their difference.")
+			break
+		}
+		if t.compare.Differing() {
+			t.compare.SetDifference(app, nil)
+			break
+		}
+		t.prompt.Start(app, ".", func(app gowid.IApp, field string) {
+			if field == "" {
+				return
+			}
+			t.compare.SetDifference(app, strings.Split(field, "."))
+		})
+	case actionDetails:
+		// Enter picks a field in the schema instead.
+		if t.view.Focus() == 1 && t.resultsLayout.Focused(t.schema) {
+			return false
+		}
+		// The details are of B's result while B is in focus.
+		if t.view.Focus() == 1 && t.resultsLayout.Focused(t.compare) {
+			showDetail(app, t.compare.Walker(), t.env.root)
+			break
+		}
+		showDetail(app, t.results.Walker(), t.env.root)
+	case actionComplete:
+		if !typing {
+			return false
+		}
+		t.status.SetHint(app, t.completer.Complete(app))
+	case actionSearch:
+		if !typing {
+			return false
+		}
+		t.status.SetHint(app, t.recall.Search())
+	default:
+		return false
+	}
+	return true
+}
+
+// handleResultAction takes the action on the results, and returns whether it
+// is one. It is meant for when the results are in focus.
+func (t *tab) handleResultAction(app gowid.IApp, a action) bool {
+	if t.results.HandleTableAction(a) {
+		return true
+	}
+
+	switch a {
+	case actionYank, actionYankPretty:
+		t.status.SetHint(app, yankResult(t.results, a == actionYankPretty))
+	case actionYankField:
+		t.status.SetHint(app, yankField(t.results))
+	case actionPipe:
+		t.prompt.Start(app, "|", func(app gowid.IApp, command string) {
+			pipe(app, t.env.loop, t.results.Walker().Data(), command, t.env.cfg.Pretty, t.status)
+		})
+	case actionMark:
+		index, d, err := t.results.Focused()
+		if err != nil {
+			t.status.SetHint(app, "There is no result to mark.")
+			break
+		}
+		t.marked, t.markedIndex = d, index
+		t.status.SetHint(app, fmt.Sprintf("Marked result #%d. %s diffs it against the result in focus.", index, t.env.keys.Label(actionDiffMarked)))
+	case actionDiffMarked:
+		if t.marked == nil {
+			t.status.SetHint(app, fmt.Sprintf("Mark a result with %s to diff others against it.", t.env.keys.Label(actionMark)))
+			break
+		}
+		showDiff(app, t.marked, t.markedIndex, t.results, t.env.root)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/list"
+	"github.com/gcla/gowid/widgets/palettemap"
+	"github.com/gcla/gowid/widgets/pile"
+	"github.com/gcla/gowid/widgets/selectable"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/internal/generics"
+	"github.com/utagai/look/query/breeze"
+)
+
+const (
+	// maxDefaultColumnWidth caps the width that columns start out with, so that
+	// a single long value doesn't push every other column out of view.
+	maxDefaultColumnWidth = 30
+	minColumnWidth        = 1
+	columnSeparator       = " │ "
+)
+
+// columnSettings are the settings of a column, which the user can change. They
+// are kept even while the column is out of view, and across queries.
+type columnSettings struct {
+	width  int
+	hidden bool
+	pinned bool
+}
+
+// tableLayout decides which columns the table shows and how. The columns are
+// the union of the top-level fields of the datums around the focus, unless the
+// query picked them with a select stage.
+type tableLayout struct {
+	settings map[string]*columnSettings
+	// selected are the columns picked by the query's select stage, if any.
+	selected []string
+	// columns are the table's columns, as of the last render.
+	columns []string
+	// focus is the column in focus.
+	focus string
+	// scroll is the index, among the unpinned columns that aren't hidden, of
+	// the first one shown.
+	scroll int
+	// width is the width of the table, as of the last render.
+	width int
+}
+
+func newTableLayout() *tableLayout {
+	return &tableLayout{settings: map[string]*columnSettings{}}
+}
+
+// selectedColumns returns the top-level fields picked by the last stage of the
+// query, if that stage is a select stage.
+func selectedColumns(q string) []string {
+	stages, err := breeze.NewParser(q).Parse()
+	if err != nil || len(stages) == 0 {
+		return nil
+	}
+
+	sel, ok := stages[len(stages)-1].(*breeze.Select)
+	if !ok {
+		return nil
+	}
+
+	columns := []string{}
+	seen := map[string]bool{}
+	for _, field := range sel.Fields {
+		column := strings.SplitN(field, ".", 2)[0]
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+// infer works out the columns from the given datums, and returns whether they
+// changed.
+func (l *tableLayout) infer(datums []datum.Datum) bool {
+	previous := l.columns
+	if len(l.selected) > 0 {
+		l.columns = l.selected
+	} else {
+		seen := map[string]bool{}
+		l.columns = []string{}
+		for _, d := range datums {
+			for key := range d {
+				if !seen[key] {
+					seen[key] = true
+					l.columns = append(l.columns, key)
+				}
+			}
+		}
+		sort.Strings(l.columns)
+	}
+
+	for _, column := range l.columns {
+		if _, ok := l.settings[column]; ok {
+			continue
+		}
+
+		width := utf8.RuneCountInString(column)
+		for _, d := range datums {
+			if cellWidth := utf8.RuneCountInString(cell(d, column)); cellWidth > width {
+				width = cellWidth
+			}
+		}
+		if width > maxDefaultColumnWidth {
+			width = maxDefaultColumnWidth
+		}
+		l.settings[column] = &columnSettings{width: width}
+	}
+
+	if l.indexOf(l.focus) == -1 || l.settings[l.focus].hidden {
+		l.focus = ""
+		if shown := l.shownColumns(); len(shown) > 0 {
+			l.focus = shown[0]
+		}
+	}
+	l.scrollToFocus()
+
+	return !generics.Equal(previous, l.columns)
+}
+
+func (l *tableLayout) indexOf(column string) int {
+	for i, c := range l.columns {
+		if c == column {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// shownColumns returns the columns that aren't hidden, with the pinned columns
+// first.
+func (l *tableLayout) shownColumns() []string {
+	pinned, unpinned := l.splitColumns()
+	return append(pinned, unpinned...)
+}
+
+// splitColumns returns the pinned and unpinned columns that aren't hidden.
+func (l *tableLayout) splitColumns() ([]string, []string) {
+	pinned, unpinned := []string{}, []string{}
+	for _, column := range l.columns {
+		switch settings := l.settings[column]; {
+		case settings.hidden:
+		case settings.pinned:
+			pinned = append(pinned, column)
+		default:
+			unpinned = append(unpinned, column)
+		}
+	}
+
+	return pinned, unpinned
+}
+
+// visibleColumns returns the columns that fit in the table, given its scroll.
+// The pinned columns are always visible, followed by as many of the unpinned
+// columns as fit. At least one column is visible, even if it doesn't fit.
+func (l *tableLayout) visibleColumns() []string {
+	pinned, unpinned := l.splitColumns()
+	visible := []string{}
+	used := 0
+	for _, column := range append(pinned, unpinned[generics.Min(l.scroll, len(unpinned)):]...) {
+		used += l.settings[column].width
+		if len(visible) > 0 {
+			used += utf8.RuneCountInString(columnSeparator)
+		}
+		if used > l.width && len(visible) > 0 {
+			break
+		}
+		visible = append(visible, column)
+	}
+
+	return visible
+}
+
+// scrollToFocus scrolls the table horizontally, such that the focused column
+// is visible.
+func (l *tableLayout) scrollToFocus() {
+	_, unpinned := l.splitColumns()
+	focus := -1
+	for i, column := range unpinned {
+		if column == l.focus {
+			focus = i
+		}
+	}
+
+	if l.scroll >= len(unpinned) {
+		l.scroll = generics.Max(len(unpinned)-1, 0)
+	}
+	if focus == -1 {
+		// The focus is pinned, so it is always visible.
+		return
+	}
+	if focus < l.scroll {
+		l.scroll = focus
+	}
+	for l.scroll < focus && !generics.Contains(l.visibleColumns(), l.focus) {
+		l.scroll++
+	}
+}
+
+// moveFocus moves the focus by the given number of columns.
+func (l *tableLayout) moveFocus(delta int) {
+	shown := l.shownColumns()
+	for i, column := range shown {
+		if column == l.focus {
+			l.focus = shown[generics.Min(generics.Max(i+delta, 0), len(shown)-1)]
+			break
+		}
+	}
+	l.scrollToFocus()
+}
+
+// resizeFocus changes the width of the focused column by the given amount.
+func (l *tableLayout) resizeFocus(delta int) {
+	if settings, ok := l.settings[l.focus]; ok {
+		settings.width = generics.Max(settings.width+delta, minColumnWidth)
+	}
+	l.scrollToFocus()
+}
+
+// hideFocus hides the focused column, and moves the focus to its neighbour.
+func (l *tableLayout) hideFocus() {
+	shown := l.shownColumns()
+	if len(shown) <= 1 {
+		// Leave at least one column, so there is something left to show.
+		return
+	}
+
+	hidden := l.focus
+	l.moveFocus(1)
+	if l.focus == hidden {
+		l.moveFocus(-1)
+	}
+	l.settings[hidden].hidden = true
+	l.scrollToFocus()
+}
+
+// showAll shows all hidden columns again.
+func (l *tableLayout) showAll() {
+	for _, settings := range l.settings {
+		settings.hidden = false
+	}
+}
+
+// togglePinFocus pins or unpins the focused column.
+func (l *tableLayout) togglePinFocus() {
+	if settings, ok := l.settings[l.focus]; ok {
+		settings.pinned = !settings.pinned
+	}
+	l.scrollToFocus()
+}
+
+// header returns the content of the table's header row.
+func (l *tableLayout) header() *text.Content {
+	segments := []text.ContentSegment{}
+	for i, column := range l.visibleColumns() {
+		if i > 0 {
+			segments = append(segments, text.StringContent(columnSeparator))
+		}
+
+		style := "title"
+		if column == l.focus {
+			style = "key"
+		}
+		segments = append(segments, text.StyledContent(fit(column, l.settings[column].width), gowid.MakePaletteRef(style)))
+	}
+
+	return text.NewContent(segments)
+}
+
+// row returns the line of the table for the given datum.
+func (l *tableLayout) row(d datum.Datum) string {
+	cells := []string{}
+	for _, column := range l.visibleColumns() {
+		cells = append(cells, fit(cell(d, column), l.settings[column].width))
+	}
+
+	return strings.Join(cells, columnSeparator)
+}
+
+// cell returns the text of the datum's cell in the given column. Strings are
+// shown as they are, rather than as JSON, since the quotes are just noise.
+func cell(d datum.Datum, column string) string {
+	value, ok := d[column]
+	if !ok {
+		return ""
+	}
+
+	if s, ok := value.(string); ok {
+		return strings.ReplaceAll(s, "\n", " ")
+	}
+
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return "?"
+	}
+
+	return string(jsonValue)
+}
+
+// fit pads or truncates the string to the given width.
+func fit(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// truncate truncates the string to the given width, marking that it was.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+
+	return s
+}
+
+// tableView shows the data as a table, with a column per top-level field. Like
+// the list view, it only ever reads the datums around its focus.
+type tableView struct {
+	gowid.IWidget
+	layout *tableLayout
+	lb     *list.IndexedWidget
+	// rendered are the datums of the rows rendered last, which the columns
+	// are worked out from.
+	rendered []datum.Datum
+}
+
+func newTableView(walker *data.DataWalker) *tableView {
+	layout := newTableLayout()
+	lb := list.NewBounded(walker)
+	tv := &tableView{
+		IWidget: pile.New([]gowid.IContainerWidget{
+			&gowid.ContainerWidget{
+				IWidget: &tableHeader{Widget: text.New(""), layout: layout},
+				D:       gowid.RenderFlow{},
+			},
+			&gowid.ContainerWidget{
+				IWidget: lb,
+				D:       gowid.RenderWithWeight{W: 1},
+			},
+		}),
+		layout: layout,
+		lb:     lb,
+	}
+	tv.setRenderer(walker)
+
+	return tv
+}
+
+func (tv *tableView) setRenderer(walker *data.DataWalker) {
+	walker.SetRenderer(func(d datum.Datum) gowid.IWidget {
+		tv.rendered = append(tv.rendered, d)
+		return selectable.New(
+			palettemap.New(
+				text.NewFromContentExt(
+					text.NewContent([]text.ContentSegment{
+						text.StyledContent(tv.layout.row(d), gowid.MakePaletteRef("body")),
+					}),
+					text.Options{Wrap: text.WrapClip},
+				),
+				palettemap.Map{"body": "fbody"},
+				palettemap.Map{},
+			),
+		)
+	})
+}
+
+// SetWalker shows the data of the given walker, with the columns picked by the
+// given query, if any.
+func (tv *tableView) SetWalker(walker *data.DataWalker, q string, app gowid.IApp) {
+	tv.setRenderer(walker)
+	tv.rendered = nil
+	tv.layout.selected = selectedColumns(q)
+	tv.lb.SetWalker(walker, app)
+}
+
+// Render implements the gowid.IWidget interface. The columns are worked out
+// afresh on every render from the rows last rendered, since that is where the
+// user is looking. The rows are read as they are rendered, so if they bring
+// new columns, they are rendered again with them.
+func (tv *tableView) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	box, ok := size.(gowid.IRenderBox)
+	if !ok {
+		return tv.IWidget.Render(size, focus, app)
+	}
+
+	tv.layout.width = box.BoxColumns()
+	tv.layout.infer(tv.rendered)
+	tv.rendered = nil
+	canvas := tv.IWidget.Render(size, focus, app)
+	if tv.layout.infer(tv.rendered) {
+		tv.rendered = nil
+		canvas = tv.IWidget.Render(size, focus, app)
+	}
+
+	return canvas
+}
+
+// UserInput implements the gowid.IWidget interface.
+func (tv *tableView) UserInput(ev interface{}, size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) bool {
+	evk, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return tv.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	switch {
+	case evk.Key() == tcell.KeyLeft:
+		tv.layout.moveFocus(-1)
+	case evk.Key() == tcell.KeyRight:
+		tv.layout.moveFocus(1)
+	default:
+		return tv.IWidget.UserInput(ev, size, focus, app)
+	}
+
+	return true
+}
+
+// HandleAction takes the action on the columns, and returns whether it is one.
+func (tv *tableView) HandleAction(a action) bool {
+	switch a {
+	case actionWidenColumn:
+		tv.layout.resizeFocus(1)
+	case actionNarrowColumn:
+		tv.layout.resizeFocus(-1)
+	case actionHideColumn:
+		tv.layout.hideFocus()
+	case actionShowColumns:
+		tv.layout.showAll()
+	case actionPinColumn:
+		tv.layout.togglePinFocus()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// tableHeader is the header row of a table.
+type tableHeader struct {
+	*text.Widget
+	layout *tableLayout
+}
+
+// Render implements the gowid.IWidget interface.
+func (h *tableHeader) Render(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.ICanvas {
+	return text.NewFromContentExt(h.layout.header(), text.Options{Wrap: text.WrapClip}).Render(size, focus, app)
+}
+
+// RenderSize implements the gowid.IWidget interface.
+func (h *tableHeader) RenderSize(size gowid.IRenderSize, focus gowid.Selector, app gowid.IApp) gowid.IRenderBox {
+	return text.NewFromContentExt(h.layout.header(), text.Options{Wrap: text.WrapClip}).RenderSize(size, focus, app)
+}
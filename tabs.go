@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gcla/gowid"
+	"github.com/gcla/gowid/widgets/holder"
+	"github.com/gcla/gowid/widgets/text"
+	"github.com/gdamore/tcell"
+	"github.com/utagai/look/config"
+	"github.com/utagai/look/data"
+	"github.com/utagai/look/internal/generics"
+)
+
+// tabBar lists the tabs, with the one showing picked out.
+type tabBar struct {
+	*text.Widget
+}
+
+func newTabBar() *tabBar {
+	return &tabBar{Widget: text.New("")}
+}
+
+func (b *tabBar) update(app gowid.IApp, tabs []*tab, current int) {
+	segments := make([]text.ContentSegment, 0, len(tabs))
+	for i, t := range tabs {
+		style := gowid.MakePaletteRef("foot")
+		if i == current {
+			// The colors of the bar, reversed, as for the focus in modals.
+			style = gowid.MakePaletteRef("fmodal")
+		}
+		segments = append(segments, text.StyledContent(fmt.Sprintf(" %d %s ", i+1, t.Label()), style))
+	}
+	b.SetContent(app, text.NewContent(segments))
+}
+
+// tabSet shows one of the tabs at a time, and switches between them.
+type tabSet struct {
+	// Widget holds the view of the tab showing.
+	*holder.Widget
+	env     *tabEnv
+	bar     *tabBar
+	tabs    []*tab
+	current int
+}
+
+func newTabSet(env *tabEnv, bar *tabBar) *tabSet {
+	return &tabSet{
+		Widget: holder.New(text.New("")),
+		env:    env,
+		bar:    bar,
+	}
+}
+
+// Current returns the tab showing.
+func (s *tabSet) Current() *tab {
+	return s.tabs[s.current]
+}
+
+// Add adds the tab after the others, and shows it.
+func (s *tabSet) Add(app gowid.IApp, t *tab) {
+	s.tabs = append(s.tabs, t)
+	s.show(app, len(s.tabs)-1)
+}
+
+// CloseAll closes every tab, e.g. on exit.
+func (s *tabSet) CloseAll() {
+	for _, t := range s.tabs {
+		t.Close()
+	}
+}
+
+// Refresh updates the tab bar, e.g. once a tab's query has changed.
+func (s *tabSet) Refresh(app gowid.IApp) {
+	s.bar.update(app, s.tabs, s.current)
+}
+
+func (s *tabSet) show(app gowid.IApp, i int) {
+	s.current = i
+	s.SetSubWidget(s.Current().view, app)
+	s.Refresh(app)
+}
+
+// HandleKey handles the keys that open, close and switch between tabs, and
+// returns whether it did.
+func (s *tabSet) HandleKey(app gowid.IApp, ev *tcell.EventKey) bool {
+	current := s.Current()
+	// The prompt takes every key while it is open.
+	if current.prompt.Active() {
+		return false
+	}
+	a, bound := s.env.keys.Action(ev)
+	if !bound {
+		return false
+	}
+
+	switch a {
+	case actionNewTab:
+		// The new tab starts out as a copy of the one showing, to take in
+		// another direction.
+		s.Add(app, newTab(s.env, current.source, current.queryTextbox.Text()))
+	case actionOpen:
+		current.prompt.Start(app, "<", func(app gowid.IApp, path string) {
+			if path == "" {
+				return
+			}
+			s.open(app, path, current.status)
+		})
+	case actionCloseTab:
+		if len(s.tabs) == 1 {
+			current.status.SetHint(app, "This is the last tab. ESC exits.")
+			break
+		}
+		current.Close()
+		s.tabs = append(s.tabs[:s.current], s.tabs[s.current+1:]...)
+		s.show(app, generics.Min(s.current, len(s.tabs)-1))
+	case actionNextTab:
+		s.show(app, (s.current+1)%len(s.tabs))
+	case actionPrevTab:
+		s.show(app, (s.current+len(s.tabs)-1)%len(s.tabs))
+	default:
+		return false
+	}
+
+	return true
+}
+
+// open opens the source at the path in a new tab. The source is read in the
+// background, since it may be large, and the tab is added once it has been. How
+// it went is reported on the given status bar.
+func (s *tabSet) open(app gowid.IApp, path string, status *statusBar) {
+	status.SetHint(app, fmt.Sprintf("Opening %s…", path))
+	go func() {
+		d, err := openPath(s.env.cfg, path)
+		runErr := app.Run(gowid.RunFunction(func(app gowid.IApp) {
+			if err != nil {
+				status.SetHint(app, fmt.Sprintf("Failed to open %s: %v", path, err))
+				return
+			}
+			status.SetHint(app, "")
+			s.Add(app, newTab(s.env, newSource(path, d), ""))
+		}))
+		// The app is closing, so no tab will ever hold on to the data.
+		if runErr != nil && err == nil {
+			if closer, ok := d.(data.Closer); ok {
+				_ = closer.Close(context.Background())
+			}
+		}
+	}()
+}
+
+func openPath(cfg *config.Config, path string) (data.Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return openData(cfg, f)
+}
+
+// tabName returns the name of the source as it is shown in the tab bar.
+func tabName(source string) string {
+	return filepath.Base(source)
+}
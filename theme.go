@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gcla/gowid"
+	"github.com/utagai/look/config"
+	"github.com/utagai/look/datum"
+	"github.com/utagai/look/datum/diff"
+)
+
+// theme is a color scheme for the UI.
+type theme struct {
+	// fg and bg are the colors of the results. The result in focus has the
+	// focusBg background instead, and the focusStyle on top.
+	fg, bg, focusBg gowid.IColor
+	focusStyle      gowid.StyleAttrs
+	// barFg and barBg are the colors of the footer and of modals.
+	barFg, barBg gowid.IColor
+	// accent is the color of the keys in the footer.
+	accent gowid.IColor
+	// json is the color of each kind of token of the results' JSON.
+	json map[datum.TokenKind]gowid.IColor
+	// match is the style of the parts of the results that matched the query.
+	match gowid.PaletteEntry
+	// diff is the color of each kind of change in diffs, which are shown in
+	// modals.
+	diff map[diff.Kind]gowid.IColor
+}
+
+var themes = map[string]theme{
+	"dark": {
+		fg:      gowid.ColorWhite,
+		bg:      gowid.ColorNone,
+		focusBg: gowid.ColorBlack,
+		barFg:   gowid.ColorWhite,
+		barBg:   gowid.ColorBlack,
+		accent:  gowid.ColorCyan,
+		json: map[datum.TokenKind]gowid.IColor{
+			datum.TokenPunctuation: gowid.ColorWhite,
+			datum.TokenKey:         gowid.ColorCyan,
+			datum.TokenString:      gowid.ColorGreen,
+			datum.TokenNumber:      gowid.ColorYellow,
+			datum.TokenBool:        gowid.ColorMagenta,
+			datum.TokenNull:        gowid.ColorDarkGray,
+		},
+		match: gowid.MakePaletteEntry(gowid.ColorBlack, gowid.ColorYellow),
+		diff: map[diff.Kind]gowid.IColor{
+			diff.Added:   gowid.ColorGreen,
+			diff.Removed: gowid.ColorRed,
+			diff.Changed: gowid.ColorYellow,
+		},
+	},
+	"light": {
+		fg:      gowid.ColorBlack,
+		bg:      gowid.ColorNone,
+		focusBg: gowid.ColorLightGray,
+		barFg:   gowid.ColorBlack,
+		barBg:   gowid.ColorLightGray,
+		accent:  gowid.ColorBlue,
+		json: map[datum.TokenKind]gowid.IColor{
+			datum.TokenPunctuation: gowid.ColorBlack,
+			datum.TokenKey:         gowid.ColorBlue,
+			datum.TokenString:      gowid.ColorDarkGreen,
+			datum.TokenNumber:      gowid.ColorDarkRed,
+			datum.TokenBool:        gowid.ColorPurple,
+			datum.TokenNull:        gowid.ColorDarkGray,
+		},
+		match: gowid.MakePaletteEntry(gowid.ColorBlack, gowid.ColorYellow),
+		diff: map[diff.Kind]gowid.IColor{
+			diff.Added:   gowid.ColorDarkGreen,
+			diff.Removed: gowid.ColorDarkRed,
+			diff.Changed: gowid.ColorPurple,
+		},
+	},
+	// mono leaves the colors to the terminal, for terminals without any, or
+	// for those who'd rather not have them.
+	"mono": {
+		fg:         gowid.ColorNone,
+		bg:         gowid.ColorNone,
+		focusBg:    gowid.ColorNone,
+		focusStyle: gowid.StyleReverse,
+		barFg:      gowid.ColorNone,
+		barBg:      gowid.ColorNone,
+		accent:     gowid.ColorNone,
+		json:       map[datum.TokenKind]gowid.IColor{},
+		match:      gowid.MakeStyledPaletteEntry(gowid.ColorNone, gowid.ColorNone, gowid.StyleUnderline),
+		diff:       map[diff.Kind]gowid.IColor{},
+	},
+}
+
+// themeNames returns the names of the themes, sorted.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// getTheme returns the theme with the given name.
+func getTheme(name string) (theme, error) {
+	t, ok := themes[name]
+	if !ok {
+		return theme{}, fmt.Errorf("unknown theme %q, expected one of %v", name, themeNames())
+	}
+
+	return t, nil
+}
+
+// palette returns the gowid palette of the theme.
+func (t theme) palette() gowid.Palette {
+	palette := gowid.Palette{
+		"title": gowid.MakePaletteEntry(t.barFg, t.barBg),
+		"key":   gowid.MakePaletteEntry(t.accent, t.barBg),
+		"foot":  gowid.MakePaletteEntry(t.barFg, t.barBg),
+		"body":  gowid.MakePaletteEntry(t.fg, t.bg),
+		"fbody": gowid.MakeStyledPaletteEntry(t.fg, t.focusBg, t.focusStyle),
+		// Modals need a background, or the view beneath them shows through.
+		"modal":  gowid.MakePaletteEntry(t.barFg, t.barBg),
+		"fmodal": gowid.MakeStyledPaletteEntry(t.barBg, t.barFg, t.focusStyle),
+	}
+
+	// Matches stand out the same whether in focus or not.
+	palette[matchPaletteName] = t.match
+	palette[focused(matchPaletteName)] = t.match
+
+	for kind, name := range tokenPaletteNames {
+		fg, ok := t.json[kind]
+		if !ok {
+			fg = t.fg
+		}
+		palette[name] = gowid.MakePaletteEntry(fg, t.bg)
+		palette[focused(name)] = gowid.MakeStyledPaletteEntry(fg, t.focusBg, t.focusStyle)
+	}
+
+	for _, kind := range []diff.Kind{diff.Added, diff.Removed, diff.Changed} {
+		fg, ok := t.diff[kind]
+		if !ok {
+			fg = t.barFg
+		}
+		palette[string(kind)] = gowid.MakePaletteEntry(fg, t.barBg)
+	}
+
+	return palette
+}
+
+// overridePalette overrides the entries of the palette with the given ones. A
+// color left empty keeps the color of the entry being overridden.
+func overridePalette(palette gowid.Palette, overrides map[string]config.PaletteEntry) error {
+	for name, override := range overrides {
+		styler, ok := palette[name]
+		if !ok {
+			return fmt.Errorf("unknown palette entry %q, expected one of %v", name, paletteNames(palette))
+		}
+		entry := styler.(gowid.PaletteEntry)
+
+		if override.FG != "" {
+			fg, err := gowid.MakeColorSafe(override.FG)
+			if err != nil {
+				return fmt.Errorf("invalid foreground color for palette entry %q: %w", name, err)
+			}
+			entry.FG = fg
+		}
+		if override.BG != "" {
+			bg, err := gowid.MakeColorSafe(override.BG)
+			if err != nil {
+				return fmt.Errorf("invalid background color for palette entry %q: %w", name, err)
+			}
+			entry.BG = bg
+		}
+		palette[name] = entry
+	}
+
+	return nil
+}
+
+// paletteNames returns the names of the entries of the palette, sorted.
+func paletteNames(palette gowid.Palette) []string {
+	names := make([]string, 0, len(palette))
+	for name := range palette {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
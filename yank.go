@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// yankResult copies the result in focus to the clipboard, as compact JSON, or
+// indented if pretty is set, and returns a hint saying how it went.
+func yankResult(results *resultsView, pretty bool) string {
+	index, d, err := results.Focused()
+	if err != nil {
+		return "There is no result to copy."
+	}
+
+	out := []byte(d.String())
+	if pretty {
+		out, err = json.MarshalIndent(d, "", prettyIndent)
+		if err != nil {
+			return fmt.Sprintf("Failed to copy result #%d: %v", index, err)
+		}
+	}
+	if err := copyToClipboard(string(out)); err != nil {
+		return fmt.Sprintf("Failed to copy result #%d: %v", index, err)
+	}
+
+	return fmt.Sprintf("Copied result #%d to the clipboard.", index)
+}
+
+// yankField copies the value of the column in focus of the result in focus to
+// the clipboard, and returns a hint saying how it went. Only the table has a
+// column in focus; the details copy the values of their own fields.
+func yankField(results *resultsView) string {
+	column, ok := results.FocusedColumn()
+	if !ok {
+		return "Fields are copied from the table, or from the details of a result."
+	}
+	index, d, err := results.Focused()
+	if err != nil {
+		return "There is no result to copy."
+	}
+	value, ok := d[column]
+	if !ok {
+		return fmt.Sprintf("Result #%d has no %s.", index, column)
+	}
+
+	if err := copyToClipboard(fieldValue(value)); err != nil {
+		return fmt.Sprintf("Failed to copy %s: %v", column, err)
+	}
+
+	return fmt.Sprintf("Copied %s of result #%d to the clipboard.", column, index)
+}
+
+// fieldValue returns the value as it is copied: strings as they are, since the
+// quotes would only get in the way, and anything else as JSON.
+func fieldValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(jsonValue)
+}